@@ -5,8 +5,8 @@
 package getgo
 
 import (
+	"encoding/xml"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 
@@ -32,22 +32,68 @@ func (h Atomized) Handle(resp *http.Response) error {
 	return h.Tx.Commit()
 }
 
-// Storable is an adapter that converts a TextTask to a StorableTask.
+// wrapper is implemented by an adapter to expose the task it wraps, so that
+// optional interfaces such as Charseter can be found on the original task.
+type wrapper interface {
+	wrapped() interface{}
+}
+
+// findTask returns the first task satisfying match in the adapter chain
+// starting from t, or nil if none is found.
+func findTask(t interface{}, match func(interface{}) bool) interface{} {
+	for t != nil {
+		if match(t) {
+			return t
+		}
+		w, ok := t.(wrapper)
+		if !ok {
+			return nil
+		}
+		t = w.wrapped()
+	}
+	return nil
+}
+
+// contentTypeChecker is implemented by an adapter that rejects an accepted
+// response of an unexpected Content-Type.
+type contentTypeChecker interface {
+	checkContentType(ct string) error
+}
+
+// Storable is an adapter that converts a TextTask to a StorableTask. The
+// response body is transcoded to UTF-8 before it is passed to the TextTask.
 type Storable struct {
 	TextTask
 }
 
-// Handle implements the Handle method of StorableTask interface.
+// Handle implements the Handle method of StorableTask interface. The status
+// code is checked against the StatusPolicy of the task, 200 and 202 are
+// accepted by default and other codes result in a *StatusError.
 func (b Storable) Handle(resp *http.Response, s Storer) error {
-	// Since an HTMLTask definitely uses response's body only, it requires that
-	// status 20x is returned.
-	switch resp.StatusCode {
-	case http.StatusOK, http.StatusAccepted:
+	policy := taskStatusPolicy(b.TextTask)
+	resp, closeAll, err := policy.follow(resp)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+	switch {
+	case policy.accepts(resp.StatusCode):
 		// no-op.
+	case containsInt(policy.Gone, resp.StatusCode):
+		return handleGone(b.TextTask, s)
 	default:
-		return fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return newStatusError(resp)
+	}
+	if c, ok := b.TextTask.(contentTypeChecker); ok {
+		if err := c.checkContentType(resp.Header.Get("Content-Type")); err != nil {
+			return err
+		}
+	}
+	r, err := toUTF8(resp.Body, resp.Header.Get("Content-Type"), taskCharset(b.TextTask))
+	if err != nil {
+		return err
 	}
-	return b.TextTask.Handle(resp.Body, s)
+	return b.TextTask.Handle(r, s)
 }
 
 // Text is an adapter that converts an HTMLTask to a TextTask.
@@ -55,6 +101,8 @@ type Text struct {
 	HTMLTask
 }
 
+func (t Text) wrapped() interface{} { return t.HTMLTask }
+
 // Handle implements the Handle method of TextTask interface.
 func (t Text) Handle(r io.Reader, s Storer) error {
 	root, err := query.Parse(r)
@@ -64,13 +112,54 @@ func (t Text) Handle(r io.Reader, s Storer) error {
 	return t.HTMLTask.Handle(root, s)
 }
 
-// ToTask adapts an HTMLTask, TextTask or Task itself to a Task.
+// FeedText is an adapter that converts a FeedTask to a TextTask.
+type FeedText struct {
+	FeedTask
+}
+
+func (t FeedText) wrapped() interface{} { return t.FeedTask }
+
+// Handle implements the Handle method of TextTask interface.
+func (t FeedText) Handle(r io.Reader, s Storer) error {
+	feed, err := ParseFeed(r)
+	if err != nil {
+		return err
+	}
+	return t.FeedTask.Handle(feed, s)
+}
+
+// XMLText is an adapter that converts an XMLTask to a TextTask.
+type XMLText struct {
+	XMLTask
+}
+
+func (t XMLText) wrapped() interface{} { return t.XMLTask }
+
+// Handle implements the Handle method of TextTask interface.
+func (t XMLText) Handle(r io.Reader, s Storer) error {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(label string, r io.Reader) (io.Reader, error) {
+		return r, nil // already transcoded to UTF-8 by Storable.
+	}
+	return t.XMLTask.Handle(d, s)
+}
+
+// ToTask adapts an HTMLTask, FeedTask, XMLTask, CSVTask, TextTask, StorableTask
+// (e.g. a JSON adapter) or Task itself to a Task.
 func ToTask(t interface{}, tx Tx) Task {
 	switch task := t.(type) {
 	case HTMLTask:
 		return Atomized{Storable{Text{task}}, tx}
+	case FeedTask:
+		return Atomized{Storable{FeedText{task}}, tx}
+	case XMLTask:
+		return Atomized{Storable{XMLText{task}}, tx}
+	case CSVTask:
+		return Atomized{Storable{CSVText{task}}, tx}
 	case TextTask:
 		return Atomized{Storable{task}, tx}
+	case StorableTask:
+		return Atomized{task, tx}
 	case Task:
 		return task
 	default:
@@ -0,0 +1,91 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// Charseter is optionally implemented by a task to override the charset of
+// its responses, for sites that declare a wrong one.
+type Charseter interface {
+	Charset() string
+}
+
+const sniffLen = 1024
+
+var (
+	metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([\w:.-]+)`)
+	xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]+encoding\s*=\s*["']([\w:.-]+)["']`)
+)
+
+// toUTF8 returns a reader that transcodes r to UTF-8. The encoding is
+// determined by the override label if not empty, otherwise by the BOM, the
+// charset parameter of contentType, an XML declaration or an HTML <meta>
+// element within the first 1024 bytes. r is returned as UTF-8 if none is
+// found. Only an unknown override label is an error.
+func toUTF8(r io.Reader, contentType, override string) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	if override != "" {
+		return decodeWith(br, override)
+	}
+	head, _ := br.Peek(sniffLen) // a shorter head is returned with an error at EOF
+	if e, name, certain := charset.DetermineEncoding(head, ""); certain {
+		// a BOM is found as no content type is given.
+		if name == "utf-8" {
+			br.Discard(3)
+			return br, nil
+		}
+		return transform.NewReader(br, e.NewDecoder()), nil
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		// an unknown label, e.g. "none", falls back to sniffing.
+		if e, _ := charset.Lookup(params["charset"]); e != nil {
+			return decode(br, e), nil
+		}
+	}
+	for _, re := range []*regexp.Regexp{xmlEncoding, metaCharset} {
+		if m := re.FindSubmatch(head); m != nil {
+			if e, _ := charset.Lookup(string(m[1])); e != nil {
+				return decode(br, e), nil
+			}
+		}
+	}
+	return br, nil
+}
+
+func decodeWith(r io.Reader, label string) (io.Reader, error) {
+	e, _ := charset.Lookup(label)
+	if e == nil {
+		return nil, errors.New("unsupported charset: " + label)
+	}
+	return decode(r, e), nil
+}
+
+func decode(r io.Reader, e encoding.Encoding) io.Reader {
+	if e == encoding.Nop {
+		return r
+	}
+	return transform.NewReader(r, e.NewDecoder())
+}
+
+// taskCharset returns the charset overridden by a task wrapped in adapters.
+func taskCharset(t interface{}) (cs string) {
+	findTask(t, func(t interface{}) bool {
+		if c, ok := t.(Charseter); ok {
+			cs = c.Charset()
+		}
+		return cs != ""
+	})
+	return cs
+}
@@ -0,0 +1,45 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestToUTF8(t *testing.T) {
+	gbk := "\xc4\xe3\xba\xc3" // 你好 in GBK
+	for _, c := range []struct {
+		body, contentType, override, expected string
+	}{
+		{"你好", "", "", "你好"},
+		{"\xef\xbb\xbf你好", "", "", "你好"},
+		{gbk, "text/html; charset=gbk", "", "你好"},
+		{`<meta charset="gbk">` + gbk, "text/html", "", `<meta charset="gbk">你好`},
+		{`<meta http-equiv="Content-Type" content="text/html; charset=GB2312">` + gbk, "", "",
+			`<meta http-equiv="Content-Type" content="text/html; charset=GB2312">你好`},
+		{`<?xml version="1.0" encoding="gbk"?>` + gbk, "", "", `<?xml version="1.0" encoding="gbk"?>你好`},
+		{gbk, "text/html; charset=utf-8", "gbk", "你好"},
+		{"caf\xe9", "text/plain; charset=windows-1252", "", "café"},
+		{`<meta charset="gbk">` + gbk, "text/html; charset=none", "", `<meta charset="gbk">你好`},
+		{"你好", "text/html; charset=utf8mb4", "", "你好"},
+	} {
+		r, err := toUTF8(strings.NewReader(c.body), c.contentType, c.override)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != c.expected {
+			t.Errorf("expect %q, got %q", c.expected, buf)
+		}
+	}
+	if _, err := toUTF8(strings.NewReader(""), "", "bogus"); err == nil {
+		t.Error("expect an error for an unknown override")
+	}
+}
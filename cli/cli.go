@@ -0,0 +1,425 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package cli implements the getgo command. A program can register its own tasks
+and call Main to get a getgo command able to run them:
+
+	func main() {
+		cli.Register("goblog", golangBlogIndexTask{})
+		cli.Main()
+	}
+*/
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql" // driver of the mysql backend
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/db"
+	"github.com/hailiang/getgo/db/jsonl"
+	"github.com/hailiang/getgo/db/mysql"
+	"github.com/hailiang/getgo/db/postgres"
+	"github.com/hailiang/getgo/db/schema"
+	"github.com/hailiang/getgo/db/sqlite"
+	"github.com/hailiang/getgo/db/sqlstore"
+	"github.com/hailiang/getgo/spec"
+	"github.com/hailiang/getgo/util"
+	"github.com/hailiang/html-query"
+	_ "github.com/lib/pq"           // driver of the postgres backend
+	_ "github.com/mattn/go-sqlite3" // driver of the sqlite backend
+)
+
+var registry = make(map[string][]interface{})
+
+// Register registers tasks under a name, so that they can be run by
+// "getgo run name". The tasks can be of any type accepted by getgo.Run.
+func Register(name string, tasks ...interface{}) {
+	if _, dup := registry[name]; dup {
+		panic("getgo: Register called twice for " + name)
+	}
+	registry[name] = tasks
+}
+
+type command struct {
+	name, args, help string
+	run              func(args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"fetch", "[-o file] [-json] url", "download a page to disk", fetch},
+		{"dump", "[-css selector | -text regexp] file|url", "dump the tag paths of matching nodes", dump},
+		{"shell", "file|url", "query a page interactively with CSS, XPath or text patterns", shellCmd},
+		{"gen", "[-name struct] [-url url] [-o file] -f field=example1|example2... file|url",
+			"generate an HTMLTask skeleton from example values", genCmd},
+		{"run", "[-runner seq|concurrent] [-n workers] [-backend print|postgres|mysql|sqlite|jsonl] [-dsn dsn] [-format f] [-migrate] spec.yaml|name...",
+			"run declarative specs or registered tasks", run},
+		{"migrate", "-backend postgres|mysql|sqlite -dsn dsn [-dry-run] spec.yaml...",
+			"create or alter the tables of specs", migrate},
+		{"stats", "[-runner seq|concurrent] [-n workers] spec.yaml|name...",
+			"run without storing and count the records by type", stats},
+	}
+}
+
+// Main parses the command line and runs a subcommand.
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix("getgo: ")
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" {
+		usage(os.Stdout)
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := protect(func() error { return cmd.run(os.Args[2:]) }); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// protect converts a panic of util's Must functions to an error.
+func protect(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return f()
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: getgo command [arguments]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    %-6s %s\n           %s\n", cmd.name, cmd.args, cmd.help)
+	}
+	if len(registry) > 0 {
+		fmt.Fprintf(w, "\nRegistered tasks: %s\n", strings.Join(registered(), ", "))
+	}
+}
+
+func registered() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("getgo "+name, flag.ExitOnError)
+}
+
+func fetch(args []string) error {
+	fs := newFlagSet("fetch")
+	out := fs.String("o", "", "output file, the last segment of the URL path if empty")
+	pretty := fs.Bool("json", false, "pretty print a JSON response")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("fetch: exactly one URL is required")
+	}
+	url := fs.Arg(0)
+	file := *out
+	if file == "" {
+		file = url[strings.LastIndex(strings.TrimRight(url, "/"), "/")+1:]
+		if file == "" || strings.Contains(file, ":") {
+			file = "index.html"
+		}
+	}
+	resp := util.MustGet(url)
+	if *pretty {
+		defer resp.Close()
+		return resp.SavePrettyJSON(file)
+	}
+	return resp.Save(file)
+}
+
+func dump(args []string) error {
+	fs := newFlagSet("dump")
+	css := fs.String("css", "", "CSS selector of the nodes to dump")
+	text := fs.String("text", "", "regular expression of the text nodes to dump")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("dump: exactly one file or URL is required")
+	}
+	root := loadHTML(fs.Arg(0))
+	switch {
+	case *text != "":
+		util.DumpAllText(root, *text)
+	case *css != "":
+		util.DumpAll(root, getgo.CSS(*css))
+	default:
+		util.DumpAll(root, getgo.CSS("*"))
+	}
+	return nil
+}
+
+func loadHTML(src string) *query.Node {
+	if isHTTPURL(src) {
+		return util.MustGetHTML(src)
+	}
+	return util.MustLoadHTML(src)
+}
+
+// isHTTPURL returns true if src is an absolute http or https URL rather than a
+// file path.
+func isHTTPURL(src string) bool {
+	u, err := url.Parse(src)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// runFlags are the flags shared by run and stats.
+type runFlags struct {
+	fs      *flag.FlagSet
+	runner  *string
+	workers *int
+}
+
+func newRunFlags(name string) *runFlags {
+	fs := newFlagSet(name)
+	return &runFlags{
+		fs:      fs,
+		runner:  fs.String("runner", "seq", "runner type: seq or concurrent"),
+		workers: fs.Int("n", 4, "number of workers of the concurrent runner"),
+	}
+}
+
+func (f *runFlags) newRunner() (getgo.Runner, error) {
+	client := getgo.NewHTTPLogger(&http.Client{})
+	errHandler := getgo.ErrorHandlerFunc(func(req *http.Request, err error) error {
+		log.Printf("%v, request: %v", err, req.URL)
+		return nil
+	})
+	switch *f.runner {
+	case "seq":
+		return getgo.SequentialRunner{Client: client, ErrorHandler: errHandler}, nil
+	case "concurrent":
+		return getgo.NewConcurrentRunner(*f.workers, client, errHandler), nil
+	}
+	return nil, fmt.Errorf("unknown runner %q", *f.runner)
+}
+
+// runAll runs the specs or registered tasks named by args, each in its own
+// transaction returned by begin.
+func (f *runFlags) runAll(args []string, begin func() (getgo.Tx, error)) error {
+	if len(args) == 0 {
+		return errors.New("at least one spec file or registered task is required")
+	}
+	runner, err := f.newRunner()
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+	for _, arg := range args {
+		tx, err := begin()
+		if err != nil {
+			return err
+		}
+		if tasks, ok := registry[arg]; ok {
+			err = getgo.Run(runner, tx, tasks...)
+		} else {
+			var s *spec.Spec
+			if s, err = spec.Load(arg); err == nil {
+				err = s.Run(runner, tx)
+			}
+		}
+		if err != nil {
+			// The runner may have rolled back already, so the error of a
+			// second Rollback is ignored.
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+func run(args []string) error {
+	f := newRunFlags("run")
+	backend := f.fs.String("backend", "print", "storage backend: print, postgres, mysql, sqlite or jsonl")
+	dsn := f.fs.String("dsn", "", "data source name of the database backend, or the directory of the jsonl backend")
+	format := f.fs.String("format", "json", "output format of the print backend: json, jsonl, csv or table")
+	autoMigrate := f.fs.Bool("migrate", false, "create missing tables and columns of a SQL backend before storing")
+	f.fs.Parse(args)
+	var begin func() (getgo.Tx, error)
+	switch *backend {
+	case "print":
+		ft, err := util.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		// One printer for all the specs, so a CSV header is written once.
+		p := util.NewPrinter(util.Options{Format: ft})
+		begin = func() (getgo.Tx, error) { return p, nil }
+	case "jsonl":
+		d, err := jsonl.Open(*dsn, jsonl.Options{})
+		if err != nil {
+			return err
+		}
+		if c, ok := d.(io.Closer); ok {
+			defer c.Close()
+		}
+		begin = dbBegin(d)
+	case "postgres", "mysql", "sqlite":
+		d, err := openSQL(*backend, *dsn)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+		d.AutoMigrate = *autoMigrate
+		begin = dbBegin(d)
+	default:
+		return fmt.Errorf("unknown backend %q", *backend)
+	}
+	return f.runAll(f.fs.Args(), begin)
+}
+
+// openSQL opens a SQL backend.
+func openSQL(backend, dsn string) (*sqlstore.DB, error) {
+	var (
+		d   db.DB
+		err error
+	)
+	switch backend {
+	case "postgres":
+		d, err = postgres.Open(dsn)
+	case "mysql":
+		d, err = mysql.Open(dsn)
+	case "sqlite":
+		d, err = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unknown SQL backend %q", backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d.(*sqlstore.DB), nil
+}
+
+func migrate(args []string) error {
+	fs := newFlagSet("migrate")
+	backend := fs.String("backend", "", "SQL backend: postgres, mysql or sqlite")
+	dsn := fs.String("dsn", "", "data source name of the database")
+	dryRun := fs.Bool("dry-run", false, "print the statements without executing them")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("migrate: at least one spec file is required")
+	}
+	var tables []interface{}
+	for _, file := range fs.Args() {
+		s, err := spec.Load(file)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, s.Schema())
+	}
+	d, err := openSQL(*backend, *dsn)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if !*dryRun {
+		return d.Migrate(tables...)
+	}
+	stmts, err := d.Migration(tables...)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		fmt.Println(stmt + ";")
+	}
+	return nil
+}
+
+func dbBegin(d db.DB) func() (getgo.Tx, error) {
+	return func() (getgo.Tx, error) {
+		return d.Begin()
+	}
+}
+
+func stats(args []string) error {
+	f := newRunFlags("stats")
+	f.fs.Parse(args)
+	if f.fs.NArg() == 0 {
+		return errors.New("stats: at least one spec file or registered task is required")
+	}
+	c := &counterTx{counts: make(map[string]int)}
+	start := time.Now()
+	err := f.runAll(f.fs.Args(), func() (getgo.Tx, error) { return c, nil })
+	c.print(os.Stdout, time.Since(start))
+	return err
+}
+
+// counterTx counts the records stored by type without storing them.
+type counterTx struct {
+	counts    map[string]int
+	commits   int
+	rollbacks int
+	mu        sync.Mutex
+}
+
+func (c *counterTx) Store(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[typeName(v)]++
+	return nil
+}
+
+func (c *counterTx) Commit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commits++
+	return nil
+}
+
+func (c *counterTx) Rollback() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollbacks++
+	return nil
+}
+
+func (c *counterTx) print(w io.Writer, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for name := range c.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%-30s %d\n", name, c.counts[name])
+	}
+	fmt.Fprintf(w, "commits: %d, rollbacks: %d, elapsed: %v\n", c.commits, c.rollbacks, elapsed)
+}
+
+func typeName(v interface{}) string {
+	if r, ok := v.(*schema.Record); ok {
+		return r.Name
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
@@ -0,0 +1,175 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/getgo"
+)
+
+func TestFieldFlags(t *testing.T) {
+	var f fieldFlags
+	if err := f.Set("title=First|Second"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f) != 1 || f[0].Name != "title" || strings.Join(f[0].Examples, ",") != "First,Second" {
+		t.Fatalf("unexpected fields %v", f)
+	}
+	if err := f.Set("=x"); err == nil {
+		t.Fatal("expect an error for a missing name")
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	f := newRunFlags("run")
+	f.fs.Parse([]string{"-runner", "concurrent", "-n", "2", "a.yaml", "b"})
+	if *f.runner != "concurrent" || *f.workers != 2 || strings.Join(f.fs.Args(), ",") != "a.yaml,b" {
+		t.Fatalf("unexpected flags %v %v %v", *f.runner, *f.workers, f.fs.Args())
+	}
+	runner, err := f.newRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Close()
+
+	*f.runner = "parallel"
+	if _, err := f.newRunner(); err == nil {
+		t.Fatal("expect an error for an unknown runner")
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout.
+func captureStdout(t *testing.T, f func() error) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, _ := ioutil.ReadAll(r)
+	return string(buf)
+}
+
+// writeSpec serves a page of two links and writes a spec scraping it.
+func writeSpec(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<ul><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul>`)
+	}))
+	t.Cleanup(srv.Close)
+	file := filepath.Join(t.TempDir(), "links.yaml")
+	err := ioutil.WriteFile(file, []byte(`
+table: links
+seeds: [`+srv.URL+`]
+items: li
+fields:
+- {name: url, css: a, attr: href, key: true}
+- {name: title, css: a}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestRunPrint(t *testing.T) {
+	file := writeSpec(t)
+	for _, c := range []struct {
+		format   string
+		expected string
+	}{
+		{"json", "{\n  \"title\": \"A\",\n  \"url\": \"/a\"\n}\n{\n  \"title\": \"B\",\n  \"url\": \"/b\"\n}\n"},
+		{"jsonl", `{"title":"A","url":"/a"}` + "\n" + `{"title":"B","url":"/b"}` + "\n"},
+		{"csv", "url,title\n/a,A\n/b,B\n"},
+		{"table", "# links\nurl  title\n/a   A\n/b   B\n\n"},
+	} {
+		out := captureStdout(t, func() error { return run([]string{"-format", c.format, file, file}) })
+		expected := c.expected + c.expected
+		if c.format == "csv" {
+			expected = "url,title\n/a,A\n/b,B\n/a,A\n/b,B\n" // a single header
+		}
+		if out != expected {
+			t.Errorf("%s: expect\n%q\ngot\n%q", c.format, expected, out)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-format", "xml", file},
+		{"-backend", "oracle", file},
+		{"-format", "json"},
+		{"-format", "json", "missing.yaml"},
+	} {
+		if err := run(args); err == nil {
+			t.Errorf("expect an error for %v", args)
+		}
+	}
+}
+
+func TestRunBackends(t *testing.T) {
+	file := writeSpec(t)
+	dir := t.TempDir()
+	if err := run([]string{"-backend", "jsonl", "-dsn", dir, file}); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "links.jsonl"))
+	if err != nil || strings.Count(string(buf), "\n") != 2 {
+		t.Fatalf("expect 2 lines, got %q, %v", buf, err)
+	}
+
+	dsn := filepath.Join(dir, "test.db")
+	if err := run([]string{"-backend", "sqlite", "-dsn", dsn, "-migrate", file, file}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	var n int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM "links"`).Scan(&n); err != nil || n != 2 {
+		t.Fatalf("expect 2 rows, got %d, %v", n, err)
+	}
+}
+
+type countTask struct {
+	url string
+}
+
+func (t countTask) Request() *http.Request {
+	req, _ := http.NewRequest("GET", t.url, nil)
+	return req
+}
+
+func (t countTask) Handle(r io.Reader, s getgo.Storer) error {
+	return s.Store(&struct{ N int }{1})
+}
+
+func TestStatsRegistered(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	Register("count", countTask{srv.URL}, countTask{srv.URL})
+	defer delete(registry, "count")
+
+	out := captureStdout(t, func() error { return stats([]string{"count"}) })
+	if !strings.Contains(out, "struct { N int }") || !strings.Contains(out, " 2\n") ||
+		!strings.Contains(out, "commits: 1, rollbacks: 0") {
+		t.Fatalf("unexpected stats %q", out)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hailiang/getgo/gen"
+)
+
+// fieldFlags collects repeated -f name=example1|example2 flags.
+type fieldFlags []gen.Field
+
+func (f *fieldFlags) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *fieldFlags) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return errors.New("expect name=example1|example2")
+	}
+	*f = append(*f, gen.Field{Name: s[:i], Examples: strings.Split(s[i+1:], "|")})
+	return nil
+}
+
+func genCmd(args []string) error {
+	fs := newFlagSet("gen")
+	name := fs.String("name", "entry", "name of the record struct")
+	url := fs.String("url", "", "URL of the page, the source if it is an http(s) URL")
+	out := fs.String("o", "", "output file, standard output if empty")
+	var fields fieldFlags
+	fs.Var(&fields, "f", "field and its example values of the first items, e.g. title='First|Second'")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("gen: exactly one file or URL is required")
+	}
+	src := fs.Arg(0)
+	if *url == "" && isHTTPURL(src) {
+		*url = src
+	}
+	code, err := gen.Generate(loadHTML(src), &gen.Config{Name: *name, URL: *url, Fields: fields})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(*out, code, 0644)
+}
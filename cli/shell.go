@@ -0,0 +1,237 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/gen"
+	"github.com/hailiang/getgo/util"
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+)
+
+const shellHelp = `Commands:
+    css <selector>    find nodes by a CSS selector
+    xpath <expr>      find nodes by an XPath expression
+    text <regexp>     find text nodes matching a regular expression
+    chain [n]         print the html-query method chain of the n-th match
+    load <file|url>   load another page
+    help              print this help
+    quit              exit the shell`
+
+// shell is an interactive selector shell over a loaded page.
+type shell struct {
+	root    *query.Node
+	matches []*html.Node
+	out     io.Writer
+}
+
+func shellCmd(args []string) error {
+	if len(args) != 1 {
+		return errors.New("shell: exactly one file or URL is required")
+	}
+	sh := &shell{out: os.Stdout}
+	if err := sh.load(args[0]); err != nil {
+		return err
+	}
+	return sh.run(os.Stdin)
+}
+
+func (sh *shell) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(sh.out, "> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		cmd, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i > 0 {
+			cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if cmd == "quit" || cmd == "exit" {
+			return nil
+		}
+		if err := sh.exec(cmd, arg); err != nil {
+			fmt.Fprintln(sh.out, "error:", err)
+		}
+		fmt.Fprint(sh.out, "> ")
+	}
+	return scanner.Err()
+}
+
+func (sh *shell) exec(cmd, arg string) error {
+	switch cmd {
+	case "":
+		return nil
+	case "help":
+		fmt.Fprintln(sh.out, shellHelp)
+		return nil
+	case "load":
+		return sh.load(arg)
+	case "css":
+		nodes, err := getgo.Select(sh.root, arg)
+		if err != nil {
+			return err
+		}
+		sh.show(internalNodes(nodes))
+	case "xpath":
+		x, err := getgo.CompileXPath(arg)
+		if err != nil {
+			return err
+		}
+		sh.show(internalNodes(x.Select(sh.root)))
+	case "text":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return err
+		}
+		var nodes []*html.Node
+		walk(sh.root.InternalNode(), func(n *html.Node) {
+			if n.Type == html.TextNode && re.MatchString(n.Data) {
+				nodes = append(nodes, n)
+			}
+		})
+		sh.show(nodes)
+	case "chain":
+		i := 0
+		if arg != "" {
+			var err error
+			if i, err = strconv.Atoi(arg); err != nil {
+				return err
+			}
+		}
+		if i < 0 || i >= len(sh.matches) {
+			return fmt.Errorf("no match %d", i)
+		}
+		chain := gen.Chain(sh.matches[i])
+		if sh.matches[i].Type == html.ElementNode {
+			chain += ".Text()"
+		}
+		fmt.Fprintln(sh.out, chain)
+	default:
+		return fmt.Errorf("unknown command %q, type help for usage", cmd)
+	}
+	return nil
+}
+
+// load loads an HTML file, a URL or a recorded HTTP response (a file starting
+// with the status line, e.g. saved by curl -i).
+func (sh *shell) load(src string) error {
+	if isHTTPURL(src) {
+		root, err := util.GetHTML(src)
+		if err != nil {
+			return err
+		}
+		sh.root, sh.matches = root, nil
+		return nil
+	}
+	buf, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = bytes.NewReader(buf)
+	if bytes.HasPrefix(buf, []byte("HTTP/")) {
+		resp, err := http.ReadResponse(bufio.NewReader(r), nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		r = resp.Body
+	}
+	root, err := query.Parse(r)
+	if err != nil {
+		return err
+	}
+	sh.root, sh.matches = root, nil
+	return nil
+}
+
+func (sh *shell) show(nodes []*html.Node) {
+	sh.matches = nodes
+	for i, n := range nodes {
+		fmt.Fprintf(sh.out, "[%d] %s\n", i, nodePath(n))
+		if text := strings.Join(strings.Fields(nodeText(n)), " "); text != "" {
+			if r := []rune(text); len(r) > 80 {
+				text = string(r[:77]) + "..."
+			}
+			fmt.Fprintf(sh.out, "    %q\n", text)
+		}
+	}
+	fmt.Fprintf(sh.out, "%d match(es)\n", len(nodes))
+}
+
+func internalNodes(nodes []*query.Node) []*html.Node {
+	result := make([]*html.Node, len(nodes))
+	for i := range nodes {
+		result[i] = nodes[i].InternalNode()
+	}
+	return result
+}
+
+// nodePath returns the path of a node like html > body > div#content > p.title.
+func nodePath(n *html.Node) string {
+	var path []string
+	for ; n != nil && n.Type != html.DocumentNode; n = n.Parent {
+		path = append([]string{nodeLabel(n)}, path...)
+	}
+	return strings.Join(path, " > ")
+}
+
+func nodeLabel(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return "#text"
+	case html.ElementNode:
+		label := n.Data
+		if id := attr(n, "id"); id != "" {
+			label += "#" + id
+		}
+		for _, class := range strings.Fields(attr(n, "class")) {
+			label += "." + class
+		}
+		return label
+	}
+	return "#" + strconv.Itoa(int(n.Type))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(n *html.Node) string {
+	var b bytes.Buffer
+	walk(n, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+	})
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	return b.String()
+}
+
+// walk calls visit on the descendants of n in document order.
+func walk(n *html.Node, visit func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		visit(c)
+		walk(c, visit)
+	}
+}
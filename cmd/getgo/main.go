@@ -0,0 +1,13 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command getgo fetches, dumps and scrapes web pages without writing a
+// throwaway main package. Run "getgo help" for usage.
+package main
+
+import "github.com/hailiang/getgo/cli"
+
+func main() {
+	cli.Main()
+}
@@ -0,0 +1,36 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetStringEmpty(t *testing.T) {
+	var v struct {
+		T time.Time
+		P *time.Time
+		I int
+		B bool
+		S string
+	}
+	rv := reflect.ValueOf(&v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		if err := setString(rv.Field(i), " "); err != nil {
+			t.Fatalf("%s: %v", rv.Type().Field(i).Name, err)
+		}
+	}
+	if !v.T.IsZero() || v.P != nil || v.I != 0 || v.B || v.S != " " {
+		t.Fatalf("unexpected values %+v", v)
+	}
+	if err := setString(rv.Field(0), "2014-06-02"); err != nil || v.T.Day() != 2 {
+		t.Fatalf("unexpected time %v, %v", v.T, err)
+	}
+	if err := setString(rv.Field(0), "June"); err == nil {
+		t.Fatal("expect error for an invalid time")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/hailiang/html-query"
+	"github.com/hailiang/html-query/expr"
+	"golang.org/x/net/html"
+)
+
+// maxSelectors is the maximum number of compiled selectors cached, the cache
+// is cleared when it is full, so that it cannot grow with arbitrary selectors
+// supplied by users.
+const maxSelectors = 1024
+
+// selectors caches compiled CSS selectors.
+var selectors = struct {
+	m  map[string]cascadia.Selector
+	mu sync.RWMutex
+}{m: make(map[string]cascadia.Selector)}
+
+func compileSelector(s string) (cascadia.Selector, error) {
+	selectors.mu.RLock()
+	sel, ok := selectors.m[s]
+	selectors.mu.RUnlock()
+	if ok {
+		return sel, nil
+	}
+	sel, err := cascadia.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	selectors.mu.Lock()
+	if len(selectors.m) >= maxSelectors {
+		selectors.m = make(map[string]cascadia.Selector)
+	}
+	selectors.m[s] = sel
+	selectors.mu.Unlock()
+	return sel, nil
+}
+
+// selectAll returns the descendants of n matching sel in document order.
+func selectAll(n *html.Node, sel cascadia.Selector) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, sel.MatchAll(c)...)
+	}
+	return nodes
+}
+
+// Select returns the descendants of n matching a standard CSS selector, e.g.
+// "div#content > p.title a[href^=http]:not(.ad)". The nodes returned still
+// support html-query methods.
+func Select(n *query.Node, selector string) ([]*query.Node, error) {
+	if n == nil {
+		return nil, nil
+	}
+	sel, err := compileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	nodes := selectAll(n.InternalNode(), sel)
+	result := make([]*query.Node, len(nodes))
+	for i := range nodes {
+		result[i] = query.NewNode(nodes[i])
+	}
+	return result, nil
+}
+
+// SelectFirst returns the first descendant of n matching a CSS selector, or
+// nil if none is found.
+func SelectFirst(n *query.Node, selector string) (*query.Node, error) {
+	if n == nil {
+		return nil, nil
+	}
+	sel, err := compileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	for c := n.InternalNode().FirstChild; c != nil; c = c.NextSibling {
+		if found := sel.MatchFirst(c); found != nil {
+			return query.NewNode(found), nil
+		}
+	}
+	return nil, nil
+}
+
+// NodeAttr returns the value of the attribute key of n and whether n has the
+// attribute.
+func NodeAttr(n *query.Node, key string) (string, bool) {
+	return attrOf(n.InternalNode(), key), hasAttr(n.InternalNode(), key)
+}
+
+// NodeText returns the text of n including its descendants, with leading and
+// trailing spaces trimmed.
+func NodeText(n *query.Node) string {
+	return textOf(n.InternalNode())
+}
+
+// CSS returns an html-query checker that matches a node satisfying a CSS
+// selector, so that it can be combined with html-query methods, e.g.
+// root.Descendants(getgo.CSS("ul > li:nth-child(2n)")). It panics if the
+// selector is invalid.
+func CSS(selector string) expr.Checker {
+	sel, err := compileSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return func(n *html.Node) *html.Node {
+		if sel.Match(n) {
+			return n
+		}
+		return nil
+	}
+}
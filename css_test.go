@@ -0,0 +1,92 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+func selectTexts(t *testing.T, root *query.Node, selector string) []string {
+	nodes, err := Select(root, selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, n := range nodes {
+		texts = append(texts, textOf(n.InternalNode()))
+	}
+	return texts
+}
+
+func TestSelect(t *testing.T) {
+	root, err := query.Parse(strings.NewReader(`<ul id="list">
+		<li>1</li><li class="ad">2</li><li>3</li><li>4</li><li>5</li>
+	</ul>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		selector string
+		texts    []string
+	}{
+		{"li:nth-child(2n)", []string{"2", "4"}},
+		{"li:nth-child(2n+1)", []string{"1", "3", "5"}},
+		{"#list > li:not(.ad)", []string{"1", "3", "4", "5"}},
+		{"li:not(:nth-child(odd))", []string{"2", "4"}},
+		{"li:last-child", []string{"5"}},
+		{"p", nil},
+	} {
+		if got := selectTexts(t, root, c.selector); !reflect.DeepEqual(got, c.texts) {
+			t.Errorf("%s: expect %v, got %v", c.selector, c.texts, got)
+		}
+	}
+
+	ul, _ := SelectFirst(root, "ul")
+	if texts := selectTexts(t, ul, "ul"); texts != nil {
+		t.Errorf("expect the node itself excluded, got %v", texts)
+	}
+	if first, err := SelectFirst(root, "li.ad"); err != nil || textOf(first.InternalNode()) != "2" {
+		t.Errorf("unexpected first node, %v", err)
+	}
+	if n := root.Descendants(CSS("li:nth-child(3)")).Next(); n == nil || textOf(n.InternalNode()) != "3" {
+		t.Error("expect the CSS checker to match the third item")
+	}
+}
+
+func TestSelectInvalid(t *testing.T) {
+	root, _ := query.Parse(strings.NewReader(`<p>x</p>`))
+	for _, selector := range []string{"li[", "li:nth-child(", "::", ""} {
+		if _, err := Select(root, selector); err == nil {
+			t.Errorf("%q: expect error", selector)
+		}
+		if _, err := SelectFirst(root, selector); err == nil {
+			t.Errorf("%q: expect error", selector)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expect CSS to panic for an invalid selector")
+		}
+	}()
+	CSS("li[")
+}
+
+func TestSelectorCacheBound(t *testing.T) {
+	for i := 0; i < maxSelectors+10; i++ {
+		if _, err := compileSelector(fmt.Sprintf("li:nth-child(%d)", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	selectors.mu.RLock()
+	defer selectors.mu.RUnlock()
+	if len(selectors.m) > maxSelectors {
+		t.Fatalf("expect at most %d cached selectors, got %d", maxSelectors, len(selectors.m))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// CSVOptions controls how the CSVText adapter reads a CSV or TSV response.
+type CSVOptions struct {
+	Comma            rune   // Field delimiter, ',' if zero, '\t' for TSV
+	Comment          rune   // Comment character, disabled if zero
+	LazyQuotes       bool   // Allow quotes in unquoted fields
+	TrimLeadingSpace bool   // Ignore leading white space in a field
+	NoHeader         bool   // The first record is data rather than a header
+	Charset          string // Charset label of the file, detected if empty
+}
+
+// CSVOptioner is optionally implemented by a CSVTask to customize reading.
+type CSVOptioner interface {
+	CSVOptions() CSVOptions
+}
+
+// CSVRow is a record of a CSV file with its header.
+type CSVRow struct {
+	Header []string // Column names, nil if CSVOptions.NoHeader is set
+	Record []string // Fields of the record
+	Line   int      // Record number, starting from 1 after the header
+}
+
+// Get returns the field of a column name, or an empty string if the column
+// does not exist.
+func (r *CSVRow) Get(name string) string {
+	for i, h := range r.Header {
+		if h == name && i < len(r.Record) {
+			return r.Record[i]
+		}
+	}
+	return ""
+}
+
+// Decode maps the fields to a pointer to a struct by the header. A struct
+// field is matched by its `csv` tag, or by its name case-insensitively,
+// ignoring underscores and spaces in column names. Without a header, the
+// exported struct fields not tagged `csv:"-"` are mapped to the columns in
+// order. Fields are converted to strings, numbers, booleans, time.Time or
+// pointers to them.
+func (r *CSVRow) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("CSV row must be decoded into a pointer to a struct")
+	}
+	rv = rv.Elem()
+	t := rv.Type()
+	pos := 0
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("csv") == "-" {
+			continue // unexported or skipped
+		}
+		col := pos
+		if r.Header != nil {
+			col = r.column(f)
+		}
+		pos++
+		if col < 0 || col >= len(r.Record) {
+			continue
+		}
+		if err := setString(rv.Field(i), r.Record[col]); err != nil {
+			return fmt.Errorf("line %d, column %s: %v", r.Line, r.columnName(col), err)
+		}
+	}
+	return nil
+}
+
+// columnName returns the quoted header of column i, or its 1-based number
+// without a header.
+func (r *CSVRow) columnName(i int) string {
+	if i < len(r.Header) {
+		return fmt.Sprintf("%q", r.Header[i])
+	}
+	return fmt.Sprint(i + 1)
+}
+
+func (r *CSVRow) column(f reflect.StructField) int {
+	tag := f.Tag.Get("csv")
+	for i, h := range r.Header {
+		if tag != "" {
+			if h == tag {
+				return i
+			}
+		} else if strings.EqualFold(normalizeColumn(h), f.Name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeColumn(s string) string {
+	return strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.TrimSpace(s))
+}
+
+// CSVText is an adapter that converts a CSVTask to a TextTask. Records are read
+// and handled one by one, so a large file is never loaded into memory.
+type CSVText struct {
+	CSVTask
+}
+
+func (t CSVText) wrapped() interface{} { return t.CSVTask }
+
+// Charset implements the Charseter interface by CSVOptions.Charset.
+func (t CSVText) Charset() string {
+	if o, ok := t.CSVTask.(CSVOptioner); ok {
+		return o.CSVOptions().Charset
+	}
+	return ""
+}
+
+// Handle implements the Handle method of TextTask interface.
+func (t CSVText) Handle(r io.Reader, s Storer) error {
+	var opts CSVOptions
+	if o, ok := t.CSVTask.(CSVOptioner); ok {
+		opts = o.CSVOptions()
+	}
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.Comment = opts.Comment
+	cr.LazyQuotes = opts.LazyQuotes
+	cr.TrimLeadingSpace = opts.TrimLeadingSpace
+	cr.FieldsPerRecord = -1
+
+	var header []string
+	if !opts.NoHeader {
+		var err error
+		if header, err = cr.Read(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff") // UTF-8 BOM
+		}
+	}
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := t.CSVTask.Handle(&CSVRow{header, record, line}, s); err != nil {
+			return err
+		}
+	}
+}
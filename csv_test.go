@@ -0,0 +1,105 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type csvItem struct {
+	ID      int
+	Title   string `csv:"name"`
+	Price   *float64
+	Updated time.Time
+	Skipped string `csv:"-"`
+	note    string
+}
+
+type csvTask struct {
+	opts CSVOptions
+	rows []*CSVRow
+}
+
+func (t *csvTask) Request() *http.Request { return getReq("http://example.com/items.csv") }
+
+func (t *csvTask) CSVOptions() CSVOptions { return t.opts }
+
+func (t *csvTask) Handle(row *CSVRow, s Storer) error {
+	t.rows = append(t.rows, row)
+	var item csvItem
+	if err := row.Decode(&item); err != nil {
+		return err
+	}
+	return s.Store(item)
+}
+
+func TestCSVText(t *testing.T) {
+	task := &csvTask{}
+	tx := &memTx{}
+	body := "\ufeffid,name,PRICE,updated_,skipped\n" +
+		"1,a,1.5,2014-06-02,x\n" +
+		"2,\"b, c\",,,\n"
+	if err := (CSVText{task}).Handle(strings.NewReader(body), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.stored) != 2 {
+		t.Fatalf("expect 2 items, got %d", len(tx.stored))
+	}
+	a, b := tx.stored[0].(csvItem), tx.stored[1].(csvItem)
+	if a.ID != 1 || a.Title != "a" || a.Price == nil || *a.Price != 1.5 || a.Updated.Day() != 2 || a.Skipped != "" {
+		t.Fatalf("unexpected item %+v", a)
+	}
+	if b.ID != 2 || b.Title != "b, c" || b.Price != nil || !b.Updated.IsZero() {
+		t.Fatalf("unexpected item %+v", b)
+	}
+	if row := task.rows[1]; row.Line != 2 || row.Get("name") != "b, c" || row.Get("missing") != "" {
+		t.Fatalf("unexpected row %+v", row)
+	}
+
+	if err := (CSVText{&csvTask{}}).Handle(strings.NewReader(""), tx); err != nil {
+		t.Fatalf("expect no error for an empty file, got %v", err)
+	}
+	err := (CSVText{&csvTask{}}).Handle(strings.NewReader("id\nx\n"), tx)
+	if err == nil || !strings.Contains(err.Error(), `line 1, column "id"`) {
+		t.Fatalf("expect a conversion error, got %v", err)
+	}
+}
+
+func TestCSVTextOptions(t *testing.T) {
+	task := &csvTask{opts: CSVOptions{Comma: '\t', Comment: '#', NoHeader: true}}
+	tx := &memTx{}
+	body := "# no header\n3\tc\t2.5\n4\td\n"
+	if err := (CSVText{task}).Handle(strings.NewReader(body), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.stored) != 2 || task.rows[0].Header != nil {
+		t.Fatalf("expect 2 rows without a header, got %v", task.rows)
+	}
+	c, d := tx.stored[0].(csvItem), tx.stored[1].(csvItem)
+	if c.ID != 3 || c.Title != "c" || c.Price == nil || *c.Price != 2.5 {
+		t.Fatalf("expect the columns mapped by position, got %+v", c)
+	}
+	if d.ID != 4 || d.Title != "d" || d.Price != nil {
+		t.Fatalf("unexpected item %+v", d)
+	}
+
+	err := (CSVText{&csvTask{opts: CSVOptions{NoHeader: true}}}).Handle(strings.NewReader("1,a,x\n"), tx)
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 3") {
+		t.Fatalf("expect a conversion error, got %v", err)
+	}
+}
+
+func TestCSVDecodeInvalid(t *testing.T) {
+	row := &CSVRow{Header: []string{"id"}, Record: []string{"1"}, Line: 1}
+	var item csvItem
+	for _, v := range []interface{}{item, new(int)} {
+		if err := row.Decode(v); err == nil {
+			t.Errorf("expect an error for %T", v)
+		}
+	}
+}
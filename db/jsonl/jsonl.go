@@ -0,0 +1,273 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package jsonl implements db.DB with JSON Lines files for data handoff. Each
+record type is appended to its own file named after the table name of the
+record (See schema.NewRecord), e.g. "entry.jsonl", and deleted records are
+appended to "entry.deleted.jsonl".
+
+Stored records are buffered per transaction and appended on Commit, or
+discarded on Rollback. If a Commit fails halfway, the files written are
+truncated back to their previous sizes.
+*/
+package jsonl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hailiang/getgo/db"
+	"github.com/hailiang/getgo/db/schema"
+)
+
+// Options specifies the rotation and compression of the files.
+type Options struct {
+	// MaxSize rotates a file before it grows beyond MaxSize bytes, 0 means
+	// unlimited.
+	MaxSize int64
+
+	// MaxAge rotates a file when it has been written for MaxAge, 0 means
+	// unlimited. The start time of a file is not persisted, so after a
+	// restart, a file that already exists is aged from its modification
+	// time, i.e. the time of its last write.
+	MaxAge time.Duration
+
+	// Gzip compresses the files, named "*.jsonl.gz". Each Commit appends a
+	// gzip member, so the file is still a valid gzip stream.
+	Gzip bool
+}
+
+// Open returns a DB object that writes files under directory dir, which is
+// created if not exists. A rotated file is renamed with a timestamp suffix,
+// e.g. "entry-20140102T150405.000000000Z.jsonl".
+func Open(dir string, opts Options) (db.DB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &dbImpl{dir: dir, opts: opts, created: make(map[string]time.Time)}, nil
+}
+
+type dbImpl struct {
+	dir     string
+	opts    Options
+	created map[string]time.Time // the time a file is started
+	mu      sync.Mutex
+}
+
+func (d *dbImpl) Begin() (db.Tx, error) {
+	return &txImpl{db: d}, nil
+}
+
+type txImpl struct {
+	db *dbImpl
+	ss []interface{}
+	ds []interface{}
+	mu sync.Mutex
+}
+
+func (t *txImpl) Store(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ss = append(t.ss, v)
+	return nil
+}
+
+func (t *txImpl) Delete(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ds = append(t.ds, v)
+	return nil
+}
+
+func (t *txImpl) Commit() error {
+	t.mu.Lock()
+	ss, ds := t.ss, t.ds
+	t.ss, t.ds = nil, nil
+	t.mu.Unlock()
+
+	batches := make(map[string]*bytes.Buffer)
+	if err := encode(batches, ss, ""); err != nil {
+		return err
+	}
+	if err := encode(batches, ds, ".deleted"); err != nil {
+		return err
+	}
+	return t.db.write(batches)
+}
+
+func (t *txImpl) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ss = nil
+	t.ds = nil
+	return nil
+}
+
+// encode appends each record as a line to the batch of its file.
+func encode(batches map[string]*bytes.Buffer, vs []interface{}, suffix string) error {
+	for _, v := range vs {
+		name, line, err := marshal(v)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue // ignore nil record
+		}
+		b := batches[name+suffix]
+		if b == nil {
+			b = new(bytes.Buffer)
+			batches[name+suffix] = b
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return nil
+}
+
+// marshal returns the table name and the JSON encoding of a record. A struct
+// is encoded as is, so its json tags are respected, and a schema.Record is
+// encoded as an object of its fields.
+func marshal(v interface{}) (string, []byte, error) {
+	if r, ok := v.(*schema.Record); ok {
+		if r == nil {
+			return "", nil, nil
+		}
+		m := make(map[string]interface{}, len(r.Fields))
+		for _, f := range r.Fields {
+			m[f.Name] = f.Value
+		}
+		line, err := json.Marshal(m)
+		return r.Name, line, err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "", nil, nil
+	}
+	if reflect.Indirect(rv).Kind() != reflect.Struct {
+		return "", nil, errors.New("record must be a struct: " + rv.Type().String())
+	}
+	line, err := json.Marshal(v)
+	return schema.TableName(v), line, err
+}
+
+// write appends the batches to their files, and truncates the files written
+// back if any of them fails.
+func (d *dbImpl) write(batches map[string]*bytes.Buffer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make([]string, 0, len(batches))
+	for name := range batches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type written struct {
+		file string
+		size int64
+	}
+	var done []written
+	for _, name := range names {
+		data, err := d.format(batches[name].Bytes())
+		if err != nil {
+			return err
+		}
+		file, err := d.current(name, int64(len(data)))
+		if err == nil {
+			var size int64
+			if size, err = appendFile(file, data); err == nil {
+				done = append(done, written{file, size})
+				continue
+			}
+		}
+		for _, w := range done {
+			os.Truncate(w.file, w.size)
+		}
+		return err
+	}
+	return nil
+}
+
+// format compresses data as a gzip member if needed.
+func (d *dbImpl) format(data []byte) ([]byte, error) {
+	if !d.opts.Gzip {
+		return data, nil
+	}
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (d *dbImpl) ext() string {
+	if d.opts.Gzip {
+		return ".jsonl.gz"
+	}
+	return ".jsonl"
+}
+
+// current returns the file to append n bytes to, rotating it if it would
+// exceed MaxSize or is older than MaxAge.
+func (d *dbImpl) current(name string, n int64) (string, error) {
+	file := filepath.Join(d.dir, name+d.ext())
+	fi, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		d.created[name] = time.Now()
+		return file, nil
+	} else if err != nil {
+		return "", err
+	}
+	created, ok := d.created[name]
+	if !ok {
+		created = fi.ModTime()
+		d.created[name] = created
+	}
+	if fi.Size() > 0 &&
+		(d.opts.MaxSize > 0 && fi.Size()+n > d.opts.MaxSize ||
+			d.opts.MaxAge > 0 && time.Since(created) >= d.opts.MaxAge) {
+		rotated := filepath.Join(d.dir, name+"-"+time.Now().UTC().Format("20060102T150405.000000000Z")+d.ext())
+		if err := os.Rename(file, rotated); err != nil {
+			return "", err
+		}
+		d.created[name] = time.Now()
+	}
+	return file, nil
+}
+
+// appendFile appends data to file with a single write and syncs it, and
+// returns the size of the file before writing.
+func appendFile(file string, data []byte) (int64, error) {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Truncate(fi.Size())
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Truncate(fi.Size())
+		return 0, err
+	}
+	return fi.Size(), nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonl
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/getgo/db/schema"
+)
+
+type Entry struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func readFile(t *testing.T, file string) string {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestCommitRollback(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := d.Begin()
+	tx.Store(&Entry{1, "a"})
+	tx.Store(&schema.Record{Name: "other", Fields: schema.Fields{schema.NewField("Name", "x", true)}})
+	tx.Delete(&Entry{2, "b"})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx, _ = d.Begin()
+	tx.Store(&Entry{3, "c"})
+	tx.Rollback()
+	tx.Commit()
+
+	if got, want := readFile(t, filepath.Join(dir, "entry.jsonl")), `{"id":1,"title":"a"}`+"\n"; got != want {
+		t.Fatalf("expect %q, got %q", want, got)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "entry.deleted.jsonl")), `{"id":2,"title":"b"}`+"\n"; got != want {
+		t.Fatalf("expect %q, got %q", want, got)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "other.jsonl")), `{"name":"x"}`+"\n"; got != want {
+		t.Fatalf("expect %q, got %q", want, got)
+	}
+
+	tx, _ = d.Begin()
+	tx.Store(1)
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expect error for a non-struct record")
+	}
+}
+
+type hidden struct {
+	Title  string `json:"title"`
+	secret string
+}
+
+func (hidden) TableName() string { return "hidden_entry" }
+
+func TestUnexportedField(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := d.Begin()
+	tx.Store(hidden{Title: "a", secret: "s"})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "hidden_entry.jsonl")), `{"title":"a"}`+"\n"; got != want {
+		t.Fatalf("expect %q, got %q", want, got)
+	}
+}
+
+func TestRotateGzip(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := Open(dir, Options{MaxSize: 60, Gzip: true})
+	for i := 0; i < 3; i++ {
+		tx, _ := d.Begin()
+		tx.Store(&Entry{i, "a"})
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "entry-*.jsonl.gz"))
+	if len(files) == 0 {
+		t.Fatal("expect rotated files")
+	}
+	var lines int
+	for _, file := range append(files, filepath.Join(dir, "entry.jsonl.gz")) {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		lines += strings.Count(string(buf), "\n")
+	}
+	if lines != 3 {
+		t.Fatalf("expect 3 lines, got %d", lines)
+	}
+}
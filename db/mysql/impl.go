@@ -0,0 +1,42 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package mysql implements db.DB with a MySQL database. The driver
+github.com/go-sql-driver/mysql must be imported by the program.
+*/
+package mysql
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hailiang/getgo/db"
+	"github.com/hailiang/getgo/db/sqlstore"
+)
+
+// Open returns a DB object of MySQL database. Records are stored by
+// INSERT ... ON DUPLICATE KEY UPDATE, and a commit failed by a deadlock or a
+// lock wait timeout is retried (See sqlstore.RetryNum).
+func Open(dataSourceName string) (db.DB, error) {
+	return sqlstore.Open("mysql", dataSourceName, dialect{sqlstore.MySQL})
+}
+
+type dialect struct {
+	sqlstore.Dialect
+}
+
+// Retryable implements sqlstore.Retrier.
+func (dialect) Retryable(err error) bool {
+	var e *mysql.MySQLError
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Number {
+	case 1205, // ER_LOCK_WAIT_TIMEOUT
+		1213: // ER_LOCK_DEADLOCK
+		return true
+	}
+	return false
+}
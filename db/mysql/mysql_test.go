@@ -0,0 +1,31 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hailiang/getgo/db/sqlstore"
+)
+
+func TestRetryable(t *testing.T) {
+	d := dialect{sqlstore.MySQL}
+	for _, c := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&mysql.MySQLError{Number: 1213}, true},
+		{fmt.Errorf("%w -> query", &mysql.MySQLError{Number: 1205}), true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{errors.New("1213"), false},
+	} {
+		if got := d.Retryable(c.err); got != c.retryable {
+			t.Errorf("expect %v for %v, got %v", c.retryable, c.err, got)
+		}
+	}
+}
@@ -6,116 +6,92 @@ package postgres
 
 import (
 	"database/sql"
-	"sync"
+	"errors"
+	"strings"
 
 	"github.com/hailiang/getgo/db"
+	"github.com/hailiang/getgo/db/sqlstore"
+	"github.com/lib/pq"
 )
 
-// Open returns a DB object of PostgreSQL database.
+// Open returns a DB object of PostgreSQL database. Records are stored by
+// INSERT ... ON CONFLICT DO UPDATE, or COPY into a staging table followed by
+// a merge for large batches (See sqlstore.CopyThreshold), and a commit failed
+// by a serialization failure or a deadlock is retried (See
+// sqlstore.RetryNum).
 func Open(dataSourceName string) (db.DB, error) {
-	db, err := sql.Open("postgres", dataSourceName)
-	if err != nil {
-		return nil, err
-	}
-	err = db.Ping()
-	if err != nil {
-		return nil, err
-	}
-	return &dbImpl{db: db}, nil
-}
-
-type dbImpl struct {
-	db *sql.DB
-	mu sync.Mutex
-}
-
-func (d *dbImpl) Close() error {
-	return d.db.Close()
-}
-
-func (d *dbImpl) Begin() (db.Tx, error) {
-	return &txImpl{db: d}, nil
-}
-
-func (d *dbImpl) Lock() {
-	d.mu.Lock()
-}
-
-func (d *dbImpl) Unlock() {
-	d.mu.Unlock()
+	return sqlstore.Open("postgres", dataSourceName, dialect{sqlstore.Postgres})
 }
 
-type txImpl struct {
-	db *dbImpl
-	ss []interface{}
-	ds []interface{}
-	mu sync.Mutex
+type dialect struct {
+	sqlstore.Dialect
 }
 
-func (t *txImpl) Store(v interface{}) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.ss = append(t.ss, v)
-	return nil
-}
-
-func (t *txImpl) Delete(v interface{}) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.ds = append(t.ds, v)
-	return nil
+// Retryable implements sqlstore.Retrier.
+func (dialect) Retryable(err error) bool {
+	var e *pq.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Code {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
 }
 
-func (t *txImpl) Commit() error {
-	t.db.Lock()
-	defer t.db.Unlock()
-
-	rawdb := t.db.db
-	tx, err := rawdb.Begin()
+// staging is the name of the temporary table for COPY.
+const staging = "getgo_staging"
+
+// Copy implements sqlstore.Copier.
+func (d dialect) Copy(tx *sql.Tx, table string, cols, keys []string, rows [][]interface{}) error {
+	quoted := make([]string, len(cols))
+	var rest []string
+	for i, c := range cols {
+		quoted[i] = d.Quote(c)
+		if !contains(keys, c) {
+			rest = append(rest, c)
+		}
+	}
+	colList := strings.Join(quoted, ", ")
+	if _, err := tx.Exec("CREATE TEMP TABLE " + d.Quote(staging) + " AS SELECT " + colList +
+		" FROM " + d.Quote(table) + " WITH NO DATA"); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(pq.CopyIn(staging, cols...))
 	if err != nil {
 		return err
 	}
-	if err := storeRecords(t.ss, tx); err != nil {
-		tx.Rollback()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
 		return err
 	}
-	if err := deleteRecords(t.ds, tx); err != nil {
-		tx.Rollback()
+	if err := stmt.Close(); err != nil {
 		return err
 	}
-	return tx.Commit()
-}
-
-func (t *txImpl) Rollback() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.ss = nil
-	t.ds = nil
-	return nil
-}
-
-// TODO: merge storeRecords and Delete records
-
-func storeRecords(vs []interface{}, tx *sql.Tx) error {
-	if len(vs) == 0 {
-		return nil
+	merge := "INSERT INTO " + d.Quote(table) + " (" + colList + ") SELECT " + colList + " FROM " + d.Quote(staging)
+	if len(keys) > 0 {
+		merge += " " + d.OnConflict(keys, rest)
 	}
-	for _, v := range vs {
-		if err := upsert(tx, v); err != nil {
-			return err
-		}
+	if _, err := tx.Exec(merge); err != nil {
+		return err
 	}
-	return nil
+	_, err = tx.Exec("DROP TABLE " + d.Quote(staging))
+	return err
 }
 
-func deleteRecords(vs []interface{}, tx *sql.Tx) error {
-	if len(vs) == 0 {
-		return nil
-	}
-	for _, v := range vs {
-		if err := deleteRecord(tx, v); err != nil {
-			return err
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
 		}
 	}
-	return nil
+	return false
 }
@@ -5,10 +5,13 @@
 package postgres
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/hailiang/getgo/db/schema"
+	"github.com/hailiang/getgo/db/sqlstore"
+	"github.com/lib/pq"
 )
 
 func TestSqlstore(t *testing.T) {
@@ -27,7 +30,25 @@ func TestSqlstore(t *testing.T) {
 		fmt.Println(f)
 	}
 
-	fmt.Println(insertIgnoreQuery(r))
-	fmt.Println(updateQuery(r))
-	fmt.Println(deleteQuery(r))
+	b := sqlstore.Builder{Dialect: sqlstore.Postgres}
+	fmt.Println(b.InsertIgnore(r))
+	fmt.Println(b.Update(r))
+	fmt.Println(b.Delete(r))
+}
+
+func TestRetryable(t *testing.T) {
+	d := dialect{sqlstore.Postgres}
+	for _, c := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&pq.Error{Code: "40001"}, true},
+		{fmt.Errorf("%w -> query", &pq.Error{Code: "40P01"}), true},
+		{&pq.Error{Code: "23505"}, false},
+		{errors.New("40001"), false},
+	} {
+		if got := d.Retryable(c.err); got != c.retryable {
+			t.Errorf("expect %v for %v, got %v", c.retryable, c.err, got)
+		}
+	}
 }
@@ -109,22 +109,115 @@ type Record struct {
 	Fields Fields // Fields in the table
 }
 
+// sqlTag is a parsed `sql` tag of comma separated options:
+//
+//	pk              primary key
+//	name:<name>     column name, instead of the snake case of the field name
+//	type:<type>     column type, instead of the one mapped from the field type
+//	unique          unique index
+//	index           non-unique index
+//	default:<expr>  default value, which cannot contain commas
+//
+// A field tagged `sql:"-"` is not a column, and an unknown option is an error.
 type sqlTag struct {
-	Pk bool
+	Pk      bool
+	Skip    bool
+	Name    string
+	Type    string
+	Unique  bool
+	Index   bool
+	Default string
 }
 
-func parseSqlTag(tag string) *sqlTag {
+func parseSqlTag(tag string) (*sqlTag, error) {
 	sqlTag := &sqlTag{}
 	specs := strings.Split(tag, ",")
 	for _, spec := range specs {
-		if spec == "pk" {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+		case spec == "-":
+			sqlTag.Skip = true
+		case spec == "pk":
 			sqlTag.Pk = true
+		case spec == "unique":
+			sqlTag.Unique = true
+		case spec == "index":
+			sqlTag.Index = true
+		case strings.HasPrefix(spec, "name:"):
+			sqlTag.Name = strings.TrimSpace(spec[len("name:"):])
+		case strings.HasPrefix(spec, "type:"):
+			sqlTag.Type = strings.TrimSpace(spec[len("type:"):])
+		case strings.HasPrefix(spec, "default:"):
+			sqlTag.Default = strings.TrimSpace(spec[len("default:"):])
+		default:
+			return nil, fmt.Errorf("unknown sql tag option %q in %q", spec, tag)
 		}
 	}
-	return sqlTag
+	return sqlTag, nil
 }
 
-// NewRecord creates a Record from an object.
+// fieldTag returns the parsed `sql` tag of field f of struct type t, and
+// panics on an invalid tag like NewRecord and NewTable do on a non-struct.
+func fieldTag(t reflect.Type, f reflect.StructField) *sqlTag {
+	tag, err := parseSqlTag(f.Tag.Get("sql"))
+	if err != nil {
+		panic(fmt.Errorf("%v.%s: %v", t, f.Name, err))
+	}
+	return tag
+}
+
+// columnName returns the column name of a struct field.
+func (t *sqlTag) columnName(f reflect.StructField) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return camelToSnake(f.Name)
+}
+
+// Tabler is implemented by a record type to override its table name.
+type Tabler interface {
+	TableName() string
+}
+
+// tableName returns the table name of struct value v of type t.
+func tableName(v reflect.Value, t reflect.Type) string {
+	if tb, ok := v.Interface().(Tabler); ok {
+		return tb.TableName()
+	}
+	if v.CanAddr() {
+		if tb, ok := v.Addr().Interface().(Tabler); ok {
+			return tb.TableName()
+		}
+	} else if tb, ok := reflect.New(t).Interface().(Tabler); ok {
+		return tb.TableName()
+	}
+	return camelToSnake(t.Name())
+}
+
+// TableName returns the table name of a record without reading its fields, so
+// that it works with unexported fields. v is a *Record, a struct or a pointer
+// to a struct, and an empty string is returned for a nil pointer.
+func TableName(v interface{}) string {
+	if r, ok := v.(*Record); ok {
+		if r == nil {
+			return ""
+		}
+		return r.Name
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	return tableName(rv, rv.Type())
+}
+
+// NewRecord creates a Record from an object. The table name is returned by its
+// TableName method if it implements Tabler, and the columns are declared by
+// `sql` tags (See sqlTag).
 func NewRecord(s interface{}) *Record {
 	v := reflect.ValueOf(s)
 	if v.Kind() == reflect.Ptr {
@@ -140,13 +233,18 @@ func NewRecord(s interface{}) *Record {
 	}
 
 	t := v.Type()
-	name := camelToSnake(t.Name())
+	name := tableName(v, t)
 
 	// extract key value pairs
-	fields := make(Fields, t.NumField())
+	fields := make(Fields, 0, t.NumField())
 	for i := 0; i < t.NumField(); i++ {
-		sqlTag := parseSqlTag(t.Field(i).Tag.Get("sql"))
-		fields[i] = NewField(t.Field(i).Name, v.Field(i).Interface(), sqlTag.Pk)
+		sqlTag := fieldTag(t, t.Field(i))
+		if sqlTag.Skip {
+			continue
+		}
+		field := NewField(t.Field(i).Name, v.Field(i).Interface(), sqlTag.Pk)
+		field.Name = sqlTag.columnName(t.Field(i))
+		fields = append(fields, field)
 	}
 
 	if len(fields) > 0 &&
@@ -163,9 +261,9 @@ func NewRecord(s interface{}) *Record {
 // SetKey sets the primary keys of a record.
 func (r *Record) SetKey(names ...string) *Record {
 	for _, name := range names {
-		name = camelToSnake(name)
+		snake := camelToSnake(name)
 		for i := range r.Fields {
-			if r.Fields[i].Name == name {
+			if r.Fields[i].Name == name || r.Fields[i].Name == snake {
 				r.Fields[i].IsKey = true
 			}
 		}
@@ -179,3 +277,79 @@ var lowerUpper = regexp.MustCompile(`([\P{Lu}])([\p{Lu}])`)
 func camelToSnake(s string) string {
 	return strings.ToLower(lowerUpper.ReplaceAllString(s, `${1}_${2}`))
 }
+
+// Column describes a column of a table.
+type Column struct {
+	Name     string
+	Type     reflect.Type // Type of the field value, pointers dereferenced
+	SQLType  string       // Explicit column type, overrides Type if not empty
+	IsKey    bool
+	Nullable bool
+	Unique   bool
+	Index    bool
+	Default  string // Default value expression
+}
+
+// Table describes the columns of a table.
+type Table struct {
+	Name    string
+	Columns []*Column
+}
+
+// NewTable creates a Table from a struct type, named and declared the same as
+// NewRecord.
+// Fields of pointer types are nullable, and fields of types not supported by
+// the database driver (See DbType) are skipped. s can also be a Record, whose
+// columns are derived from its non-nil values.
+func NewTable(s interface{}) *Table {
+	if r, ok := s.(*Record); ok {
+		t := &Table{Name: r.Name}
+		for _, f := range r.Fields.Filter(NonNil, DbType) {
+			t.Columns = append(t.Columns, &Column{
+				Name:     f.Name,
+				Type:     reflect.TypeOf(f.Value),
+				IsKey:    f.IsKey,
+				Nullable: !f.IsKey})
+		}
+		return t
+	}
+
+	typ := reflect.TypeOf(s)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic(fmt.Errorf("table must be derived from a struct. %v", typ))
+	}
+
+	t := &Table{Name: tableName(reflect.New(typ).Elem(), typ)}
+	first := true
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := fieldTag(typ, f)
+		if f.PkgPath != "" || tag.Skip {
+			continue
+		}
+		ft := f.Type
+		nullable := ft.Kind() == reflect.Ptr
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		c := &Column{
+			Name:     tag.columnName(f),
+			Type:     ft,
+			SQLType:  tag.Type,
+			IsKey:    tag.Pk || first && tag.columnName(f) == "id",
+			Nullable: nullable,
+			Unique:   tag.Unique,
+			Index:    tag.Index,
+			Default:  tag.Default,
+		}
+		first = false
+		if !DbType(&Field{Value: reflect.Zero(ft).Interface()}) {
+			continue
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	return t
+}
@@ -0,0 +1,90 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schema
+
+import "testing"
+
+type page struct {
+	IDOther int    `sql:"name:id_other,pk"`
+	URL     string `sql:"name:url,unique"`
+	Skipped string `sql:"-"`
+	Views   *int   `sql:"type:SMALLINT,default:0,index"`
+}
+
+func (*page) TableName() string { return "pages" }
+
+func TestNewRecord(t *testing.T) {
+	for _, v := range []interface{}{&page{IDOther: 1, URL: "u"}, page{IDOther: 1, URL: "u"}} {
+		r := NewRecord(v)
+		if r.Name != "pages" {
+			t.Fatalf("expect table pages, got %s", r.Name)
+		}
+		if len(r.Fields) != 3 || r.Fields[0].Name != "id_other" || !r.Fields[0].IsKey ||
+			r.Fields[1].Name != "url" || r.Fields[1].IsKey || r.Fields[2].Name != "views" {
+			t.Fatalf("unexpected fields %v %v %v", r.Fields[0], r.Fields[1], r.Fields[2])
+		}
+	}
+}
+
+func TestTableName(t *testing.T) {
+	type blogEntry struct{ title string }
+	for _, c := range []struct {
+		v    interface{}
+		name string
+	}{
+		{page{}, "pages"},
+		{&page{}, "pages"},
+		{(*page)(nil), ""},
+		{blogEntry{}, "blog_entry"},
+		{&Record{Name: "r"}, "r"},
+	} {
+		if name := TableName(c.v); name != c.name {
+			t.Errorf("expect %q, got %q", c.name, name)
+		}
+	}
+}
+
+func TestNewTable(t *testing.T) {
+	tb := NewTable((*page)(nil))
+	if tb.Name != "pages" || len(tb.Columns) != 3 {
+		t.Fatalf("unexpected table %+v", tb)
+	}
+	url, views := tb.Columns[1], tb.Columns[2]
+	if !url.Unique || url.Nullable || url.Index {
+		t.Fatalf("unexpected column %+v", url)
+	}
+	if !views.Nullable || !views.Index || views.SQLType != "SMALLINT" || views.Default != "0" {
+		t.Fatalf("unexpected column %+v", views)
+	}
+}
+
+func TestParseSqlTag(t *testing.T) {
+	tag, err := parseSqlTag(" pk, name: n ,")
+	if err != nil || !tag.Pk || tag.Name != "n" {
+		t.Fatalf("unexpected tag %+v, %v", tag, err)
+	}
+	for _, s := range []string{"primary", "pk,uniq", "Index"} {
+		if _, err := parseSqlTag(s); err == nil {
+			t.Errorf("expect an error for %q", s)
+		}
+	}
+
+	type invalid struct {
+		ID int `sql:"primary"`
+	}
+	for _, f := range []func(){
+		func() { NewRecord(&invalid{}) },
+		func() { NewTable(invalid{}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expect a panic for an unknown tag option")
+				}
+			}()
+			f()
+		}()
+	}
+}
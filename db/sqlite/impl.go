@@ -0,0 +1,21 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package sqlite implements db.DB with a SQLite database file, so that small
+crawls and tests can store records without a database server. The driver
+github.com/mattn/go-sqlite3 must be imported by the program.
+*/
+package sqlite
+
+import (
+	"github.com/hailiang/getgo/db"
+	"github.com/hailiang/getgo/db/sqlstore"
+)
+
+// Open returns a DB object of a SQLite database, dataSourceName is the file
+// name or a URI accepted by the sqlite3 driver.
+func Open(dataSourceName string) (db.DB, error) {
+	return sqlstore.Open("sqlite3", dataSourceName, sqlstore.SQLite)
+}
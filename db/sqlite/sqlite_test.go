@@ -0,0 +1,232 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hailiang/getgo/db/sqlstore"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type entry struct {
+	ID    int `sql:"pk"`
+	Title string
+	Score *int
+}
+
+const entryTable = `CREATE TABLE "entry" ("id" INTEGER PRIMARY KEY, "title" TEXT, "score" INTEGER)`
+
+// openTest opens a database file in a temporary directory, created by schema
+// and closed at the end of the test, returning the raw connection to check it
+// and the DB under test.
+func openTest(t *testing.T, schema string) (*sql.DB, *sqlstore.DB) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "test.db")
+	raw, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { raw.Close() })
+	if _, err := raw.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := d.(*sqlstore.DB)
+	t.Cleanup(func() { s.Close() })
+	return raw, s
+}
+
+func TestStore(t *testing.T) {
+	raw, d := openTest(t, entryTable)
+
+	score := 5
+	tx, _ := d.Begin()
+	tx.Store(&entry{ID: 1, Title: "a", Score: &score})
+	tx.Store(&entry{ID: 2, Title: "b"})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, _ = d.Begin()
+	tx.Store(&entry{ID: 1, Title: "c"}) // nil Score is not updated
+	tx.Delete(&entry{ID: 2, Title: "b"})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, _ = d.Begin()
+	tx.Store(&entry{ID: 3, Title: "d"})
+	tx.Rollback()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := raw.Query(`SELECT "id", "title", "score" FROM "entry" ORDER BY "id"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.ID, &e.Title, &e.Score); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].ID != 1 || got[0].Title != "c" || got[0].Score == nil || *got[0].Score != 5 {
+		t.Fatalf("unexpected rows %+v", got)
+	}
+}
+
+func TestConcurrentCommit(t *testing.T) {
+	raw, d := openTest(t, entryTable)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, _ := d.Begin()
+			for j := 0; j < 10; j++ {
+				tx.Store(&entry{ID: j, Title: "t"})
+			}
+			errs <- tx.Commit()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n int
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM "entry"`).Scan(&n); err != nil || n != 10 {
+		t.Fatalf("expect 10 rows, got %d, %v", n, err)
+	}
+}
+
+func TestBulk(t *testing.T) {
+	raw, d := openTest(t, entryTable)
+	d.BatchSize = 7
+
+	tx, _ := d.Begin()
+	for i := 0; i < 100; i++ {
+		score := i
+		tx.Store(&entry{ID: i, Title: "a", Score: &score})
+	}
+	tx.Store(&entry{ID: 1, Title: "b"})                  // score is kept
+	tx.Store(&entry{ID: 2, Title: "c", Score: new(int)}) // the latter wins
+	tx.Store(&entry{ID: 1, Title: "d"})                  // stored after "b"
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM "entry"`).Scan(&n); err != nil || n != 100 {
+		t.Fatalf("expect 100 rows, got %d, %v", n, err)
+	}
+	for _, c := range []entry{{ID: 1, Title: "d", Score: intPtr(1)}, {ID: 2, Title: "c", Score: intPtr(0)}, {ID: 99, Title: "a", Score: intPtr(99)}} {
+		var e entry
+		if err := raw.QueryRow(`SELECT "id", "title", "score" FROM "entry" WHERE "id"=?`, c.ID).Scan(&e.ID, &e.Title, &e.Score); err != nil {
+			t.Fatal(err)
+		}
+		if e.Title != c.Title || *e.Score != *c.Score {
+			t.Fatalf("expect %v %v, got %v %v", c.Title, *c.Score, e.Title, *e.Score)
+		}
+	}
+}
+
+// logEntry has no primary key, so each record stored is a new row.
+type logEntry struct {
+	Message string
+}
+
+func TestStoreKeyless(t *testing.T) {
+	raw, d := openTest(t, `CREATE TABLE "log_entry" ("message" TEXT)`)
+
+	for i := 0; i < 2; i++ {
+		tx, _ := d.Begin()
+		tx.Store(&logEntry{Message: "a"})
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, _ := d.Begin()
+	for i := 0; i < 3; i++ {
+		tx.Store(&logEntry{Message: "b"}) // not merged in a batch
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := raw.QueryRow(`SELECT COUNT(*) FROM "log_entry"`).Scan(&n); err != nil || n != 5 {
+		t.Fatalf("expect 5 rows, got %d, %v", n, err)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestMigrate(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := d.(*sqlstore.DB)
+	defer s.Close()
+
+	type Item struct {
+		ID    int
+		Title string
+	}
+	if err := s.Migrate(&Item{}); err != nil {
+		t.Fatal(err)
+	}
+	type item struct {
+		ID    int
+		Title string
+		Score *int
+	}
+	stmts, err := s.Migration(&item{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || stmts[0] != `ALTER TABLE "item" ADD COLUMN "score" INTEGER` {
+		t.Fatalf("unexpected migration %v", stmts)
+	}
+
+	s.AutoMigrate = true
+	tx, _ := s.Begin()
+	tx.Store(&item{ID: 1, Title: "a", Score: intPtr(3)})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if stmts, err := s.Migration(&item{}); err != nil || len(stmts) != 0 {
+		t.Fatalf("expect no migration, got %v, %v", stmts, err)
+	}
+
+	s.Close()
+	if stmts, err := s.Migration(&item{}); err == nil {
+		t.Fatalf("expect an error from a closed database, got %v", stmts)
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	if _, err := Open(filepath.Join(os.DevNull, "x", "test.db")); err == nil {
+		t.Fatal("expect error for an invalid path")
+	}
+}
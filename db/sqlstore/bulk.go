@@ -0,0 +1,154 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	sc "github.com/hailiang/getgo/db/schema"
+)
+
+var (
+	// BatchSize is the default maximum number of rows written by a single
+	// statement, see DB.BatchSize.
+	BatchSize = 500
+
+	// CopyThreshold is the minimum number of rows of a batch to be written by
+	// a Copier rather than a multi-row INSERT.
+	CopyThreshold = 100
+)
+
+// maxParams is the maximum number of bind parameters of a statement accepted
+// by all the dialects (SQLite's default limit).
+const maxParams = 32766
+
+// Copier is an optional interface of a Dialect that bulk loads rows into a
+// table, e.g. by COPY into a staging table followed by a merge. The upsert
+// semantics must be preserved and rows never contain duplicated keys.
+type Copier interface {
+	Copy(tx *sql.Tx, table string, cols, keys []string, rows [][]interface{}) error
+}
+
+// batch is a group of records of the same table and columns.
+type batch struct {
+	table string
+	cols  []string
+	keys  []string
+	rows  [][]interface{}
+	index map[string]int // row index by key values
+}
+
+// batches groups records into batches while preserving the upsert semantics
+// of storing records one by one.
+type batches struct {
+	pending []*batch
+	bySig   map[string]*batch // by table and columns
+	byKey   map[string]*batch // by table and key values
+	write   func(*batch) error
+	size    func(cols int) int
+}
+
+func (bs *batches) add(r *sc.Record) error {
+	fields := r.Fields.Filter(sc.DbType, sc.NonNil)
+	cols := names(fields)
+	sig := r.Name + brace(strings.Join(cols, ","))
+	keys := r.Fields.Filter(sc.Key)
+	key := fmt.Sprintf("%s%#v", r.Name, keys.Values())
+
+	// a record stored again with different columns must be written after the
+	// previous one.
+	if b, ok := bs.byKey[key]; ok && b != bs.bySig[sig] {
+		if err := bs.flush(); err != nil {
+			return err
+		}
+	}
+	b := bs.bySig[sig]
+	if b == nil {
+		b = &batch{table: r.Name, cols: cols, keys: names(keys), index: make(map[string]int)}
+		bs.pending = append(bs.pending, b)
+		bs.bySig[sig] = b
+	}
+	switch i, ok := b.index[key]; {
+	case len(keys) == 0:
+		b.rows = append(b.rows, fields.Values()) // every record is a new row.
+	case ok:
+		b.rows[i] = fields.Values() // the same columns, the latter wins.
+	default:
+		b.index[key] = len(b.rows)
+		b.rows = append(b.rows, fields.Values())
+		bs.byKey[key] = b
+	}
+	if len(b.rows) >= bs.size(len(cols)) {
+		return bs.flush()
+	}
+	return nil
+}
+
+func (bs *batches) flush() error {
+	pending := bs.pending
+	bs.pending = nil
+	bs.bySig = make(map[string]*batch)
+	bs.byKey = make(map[string]*batch)
+	for _, b := range pending {
+		if err := bs.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchSize returns the maximum number of rows of cols columns in a batch.
+func (d *DB) batchSize(cols int) int {
+	size := d.BatchSize
+	if size <= 0 {
+		size = BatchSize
+	}
+	if cols > 0 && size > maxParams/cols {
+		size = maxParams / cols
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// storeRecords upserts records grouped by table into batches.
+func (d *DB) storeRecords(tx *sql.Tx, vs []interface{}) error {
+	bs := &batches{
+		bySig: make(map[string]*batch),
+		byKey: make(map[string]*batch),
+		write: func(b *batch) error { return d.writeBatch(tx, b) },
+		size:  d.batchSize,
+	}
+	for _, v := range vs {
+		r := record(v)
+
+		// ignore nil record
+		if r == nil {
+			continue
+		}
+
+		if err := bs.add(r); err != nil {
+			return err
+		}
+	}
+	return bs.flush()
+}
+
+func (d *DB) writeBatch(tx *sql.Tx, b *batch) error {
+	if c, ok := d.b.Dialect.(Copier); ok && len(b.rows) >= CopyThreshold {
+		if err := c.Copy(tx, b.table, b.cols, b.keys, b.rows); err != nil {
+			return fmt.Errorf("%w -> copy %d rows into %s", err, len(b.rows), b.table)
+		}
+		return nil
+	}
+	q := d.b.UpsertRows(b.table, b.cols, b.keys, b.rows)
+	if _, err := q.Do(tx); err != nil {
+		return fmt.Errorf("%w -> upsert %d rows into %s: %s", err, len(b.rows), b.table, q.Cmd)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlstore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Dialect describes the SQL syntax of a database.
+type Dialect interface {
+	// Quote quotes an identifier.
+	Quote(ident string) string
+
+	// Placeholder returns the n-th (1-based) bind parameter.
+	Placeholder(n int) string
+
+	// OnConflict returns the clause following "INSERT ... VALUES (...)" that
+	// updates the columns rest when a row of the same keys exists. keys is
+	// never empty.
+	OnConflict(keys, rest []string) string
+
+	// Type returns the column type of a Go type, or an empty string if it is
+	// not supported. key tells whether the column is a primary key or
+	// indexed.
+	Type(t reflect.Type, key bool) string
+
+	// TableExists returns a query with a ? parameter of the table name, which
+	// returns a row if the table exists in the current schema.
+	TableExists() string
+}
+
+var (
+	// Postgres is the dialect of PostgreSQL.
+	Postgres Dialect = postgres{}
+
+	// MySQL is the dialect of MySQL 8.0 or later.
+	MySQL Dialect = mysql{}
+
+	// SQLite is the dialect of SQLite 3.24 or later.
+	SQLite Dialect = sqlite{}
+)
+
+type postgres struct{}
+
+func (postgres) Quote(ident string) string {
+	return `"` + strings.Replace(ident, `"`, `""`, -1) + `"`
+}
+
+func (postgres) Placeholder(n int) string {
+	return fmt.Sprint("$", n)
+}
+
+func (d postgres) OnConflict(keys, rest []string) string {
+	return excludedConflict(d, keys, rest)
+}
+
+func (postgres) TableExists() string {
+	return "SELECT 1 FROM information_schema.tables WHERE table_schema=current_schema() AND table_name=?"
+}
+
+func (postgres) Type(t reflect.Type, key bool) string {
+	return typeName(t, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int8:    "SMALLINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Uint8:   "SMALLINT",
+		reflect.Int32:   "INTEGER",
+		reflect.Uint16:  "INTEGER",
+		reflect.Int:     "BIGINT",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint32:  "BIGINT",
+		reflect.Uint:    "NUMERIC(20)",
+		reflect.Uint64:  "NUMERIC(20)",
+		reflect.Float32: "REAL",
+		reflect.Float64: "DOUBLE PRECISION",
+		reflect.String:  "TEXT",
+	}, "TIMESTAMP WITH TIME ZONE", "BYTEA")
+}
+
+type mysql struct{}
+
+func (mysql) Quote(ident string) string {
+	return "`" + strings.Replace(ident, "`", "``", -1) + "`"
+}
+
+func (mysql) Placeholder(int) string {
+	return "?"
+}
+
+func (d mysql) OnConflict(keys, rest []string) string {
+	if len(rest) == 0 {
+		rest = keys[:1] // MySQL requires at least one assignment.
+	}
+	return "ON DUPLICATE KEY UPDATE " + list(len(rest), ", ", func(i int) string {
+		return d.Quote(rest[i]) + "=VALUES(" + d.Quote(rest[i]) + ")"
+	})
+}
+
+func (mysql) TableExists() string {
+	return "SELECT 1 FROM information_schema.tables WHERE table_schema=DATABASE() AND table_name=?"
+}
+
+func (mysql) Type(t reflect.Type, key bool) string {
+	str := "LONGTEXT"
+	if key {
+		str = "VARCHAR(255)" // TEXT cannot be indexed without a length.
+	}
+	return typeName(t, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int8:    "TINYINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Uint8:   "TINYINT UNSIGNED",
+		reflect.Uint16:  "SMALLINT UNSIGNED",
+		reflect.Int32:   "INT",
+		reflect.Uint32:  "INT UNSIGNED",
+		reflect.Int:     "BIGINT",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint:    "BIGINT UNSIGNED",
+		reflect.Uint64:  "BIGINT UNSIGNED",
+		reflect.Float32: "FLOAT",
+		reflect.Float64: "DOUBLE",
+		reflect.String:  str,
+	}, "DATETIME(6)", "LONGBLOB")
+}
+
+type sqlite struct{}
+
+func (sqlite) Quote(ident string) string {
+	return postgres{}.Quote(ident)
+}
+
+func (sqlite) Placeholder(int) string {
+	return "?"
+}
+
+func (d sqlite) OnConflict(keys, rest []string) string {
+	return excludedConflict(d, keys, rest)
+}
+
+func (sqlite) TableExists() string {
+	return "SELECT 1 FROM sqlite_master WHERE type='table' AND name=?"
+}
+
+func (sqlite) Type(t reflect.Type, key bool) string {
+	return typeName(t, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int8:    "INTEGER",
+		reflect.Int16:   "INTEGER",
+		reflect.Int32:   "INTEGER",
+		reflect.Int:     "INTEGER",
+		reflect.Int64:   "INTEGER",
+		reflect.Uint8:   "INTEGER",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "INTEGER",
+		reflect.Uint:    "INTEGER",
+		reflect.Uint64:  "INTEGER",
+		reflect.Float32: "REAL",
+		reflect.Float64: "REAL",
+		reflect.String:  "TEXT",
+	}, "TIMESTAMP", "BLOB")
+}
+
+// excludedConflict is the ON CONFLICT clause shared by PostgreSQL and SQLite.
+func excludedConflict(d Dialect, keys, rest []string) string {
+	clause := "ON CONFLICT " + brace(list(len(keys), ", ", func(i int) string { return d.Quote(keys[i]) }))
+	if len(rest) == 0 {
+		return clause + " DO NOTHING"
+	}
+	return clause + " DO UPDATE SET " + list(len(rest), ", ", func(i int) string {
+		return d.Quote(rest[i]) + "=EXCLUDED." + d.Quote(rest[i])
+	})
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// typeName looks up the column type of t by its kind, after dereferencing
+// pointers.
+func typeName(t reflect.Type, kinds map[reflect.Kind]string, timeName, bytesName string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return timeName
+	case bytesType:
+		return bytesName
+	}
+	return kinds[t.Kind()]
+}
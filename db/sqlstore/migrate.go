@@ -0,0 +1,215 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+
+	sc "github.com/hailiang/getgo/db/schema"
+)
+
+// columnType returns the explicit type of a column or the one mapped by the
+// dialect.
+func (b Builder) columnType(c *sc.Column) string {
+	if c.SQLType != "" {
+		return c.SQLType
+	}
+	return b.Type(c.Type, c.IsKey || c.Unique || c.Index)
+}
+
+// CreateTable returns the statements that create table t and its indexes.
+// Columns of types not supported by the dialect are skipped.
+func (b Builder) CreateTable(t *sc.Table) []string {
+	var defs, keys, indexes []string
+	for _, c := range t.Columns {
+		typ := b.columnType(c)
+		if typ == "" {
+			continue
+		}
+		def := b.Quote(c.Name) + " " + typ
+		if !c.Nullable || c.IsKey {
+			def += " NOT NULL"
+		}
+		if c.Default != "" {
+			def += " DEFAULT " + c.Default
+		}
+		defs = append(defs, def)
+		if c.IsKey {
+			keys = append(keys, c.Name)
+		}
+		indexes = append(indexes, b.createIndex(t.Name, c)...)
+	}
+	if len(keys) > 0 {
+		defs = append(defs, "PRIMARY KEY "+brace(b.list(keys)))
+	}
+	create := join("CREATE TABLE", b.Quote(t.Name), brace(list(len(defs), ", ", func(i int) string { return defs[i] })))
+	return append([]string{create}, indexes...)
+}
+
+// AddColumn returns the statements that add column c to a table and its
+// index. The column is nullable unless it has a default value, because of the
+// existing rows.
+func (b Builder) AddColumn(table string, c *sc.Column) []string {
+	def := join("ALTER TABLE", b.Quote(table), "ADD COLUMN", b.Quote(c.Name), b.columnType(c))
+	if c.Default != "" {
+		if !c.Nullable {
+			def += " NOT NULL"
+		}
+		def += " DEFAULT " + c.Default
+	}
+	return append([]string{def}, b.createIndex(table, c)...)
+}
+
+// createIndex returns the statement that creates the unique or non-unique
+// index of a column if needed.
+func (b Builder) createIndex(table string, c *sc.Column) []string {
+	switch {
+	case c.Unique:
+		return []string{join("CREATE UNIQUE INDEX", b.Quote(table+"_"+c.Name+"_key"), "ON", b.Quote(table), brace(b.Quote(c.Name)))}
+	case c.Index:
+		return []string{join("CREATE INDEX", b.Quote(table+"_"+c.Name+"_idx"), "ON", b.Quote(table), brace(b.Quote(c.Name)))}
+	}
+	return nil
+}
+
+// Migration returns the statements that create the missing tables or add the
+// missing columns, without executing them, e.g. for a dry run. Tables are
+// derived by schema.NewTable from structs or Records.
+func (d *DB) Migration(vs ...interface{}) ([]string, error) {
+	var stmts []string
+	for _, t := range tables(vs) {
+		exists, err := d.tableExists(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			stmts = append(stmts, d.b.CreateTable(t)...)
+			continue
+		}
+		cols, err := d.columns(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range t.Columns {
+			if !cols[c.Name] && d.b.columnType(c) != "" {
+				stmts = append(stmts, d.b.AddColumn(t.Name, c)...)
+			}
+		}
+	}
+	return stmts, nil
+}
+
+// Migrate executes the statements returned by Migration.
+func (d *DB) Migrate(vs ...interface{}) error {
+	stmts, err := d.Migration(vs...)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := d.db.Exec(stmt); err != nil {
+			return fmt.Errorf("%w -> %s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// autoMigrate migrates the tables of values unless they have been migrated
+// by this DB.
+func (d *DB) autoMigrate(vs []interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.migrated == nil {
+		d.migrated = make(map[string]map[string]bool)
+	}
+	var todo []interface{}
+	for _, t := range tables(vs) {
+		for _, c := range t.Columns {
+			if !d.migrated[t.Name][c.Name] {
+				todo = append(todo, t)
+				break
+			}
+		}
+	}
+	if err := d.Migrate(todo...); err != nil {
+		return err
+	}
+	for _, v := range todo {
+		t := v.(*sc.Table)
+		if d.migrated[t.Name] == nil {
+			d.migrated[t.Name] = make(map[string]bool)
+		}
+		for _, c := range t.Columns {
+			d.migrated[t.Name][c.Name] = true
+		}
+	}
+	return nil
+}
+
+// tables returns the tables of values, merged by table name.
+func tables(vs []interface{}) []*sc.Table {
+	var ts []*sc.Table
+	byName := make(map[string]*sc.Table)
+	for _, v := range vs {
+		var t *sc.Table
+		switch x := v.(type) {
+		case *sc.Table:
+			t = x
+		case *sc.Record:
+			if x == nil {
+				continue
+			}
+			t = sc.NewTable(x)
+		default:
+			t = sc.NewTable(v)
+		}
+		prev := byName[t.Name]
+		if prev == nil {
+			cp := *t
+			cp.Columns = append([]*sc.Column(nil), t.Columns...)
+			byName[t.Name] = &cp
+			ts = append(ts, &cp)
+			continue
+		}
+	next:
+		for _, c := range t.Columns {
+			for _, pc := range prev.Columns {
+				if pc.Name == c.Name {
+					continue next
+				}
+			}
+			prev.Columns = append(prev.Columns, c)
+		}
+	}
+	return ts
+}
+
+// tableExists returns true if the table exists.
+func (d *DB) tableExists(table string) (bool, error) {
+	var one int
+	err := d.db.QueryRow(d.b.bind(d.b.TableExists()), table).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// columns returns the column names of an existing table.
+func (d *DB) columns(table string) (map[string]bool, error) {
+	rows, err := d.db.Query(join("SELECT * FROM", d.b.Quote(table), "WHERE 1=0"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]bool, len(names))
+	for _, name := range names {
+		cols[name] = true
+	}
+	return cols, nil
+}
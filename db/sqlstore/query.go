@@ -0,0 +1,177 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlstore
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+
+	sc "github.com/hailiang/getgo/db/schema"
+)
+
+// Execer is an interface that satisfies the Exec method of sql.Tx.
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Query stores a SQL query.
+type Query struct {
+	Cmd  string
+	Args []interface{}
+}
+
+// Do executes the query on an execer provided as an argument.
+func (q *Query) Do(ex Execer) (sql.Result, error) {
+	return ex.Exec(q.Cmd, q.Args...)
+}
+
+// Builder builds queries of a record in the syntax of a Dialect.
+type Builder struct {
+	Dialect
+}
+
+// InsertIgnore returns a query that inserts a record when the primary keys
+// not exist, otherwise ignore it.
+func (b Builder) InsertIgnore(r *sc.Record) *Query {
+	fields := r.Fields.Filter(sc.DbType, sc.NonNil)
+	pkeys := r.Fields.Filter(sc.Key)
+	return &Query{
+		Cmd: b.bind(join("INSERT INTO", b.Quote(r.Name), brace(b.fieldList(fields)), "SELECT", placeholderList(fields),
+			"WHERE NOT EXISTS", brace(join("SELECT 1 FROM", b.Quote(r.Name), "WHERE", b.fieldEqualList(pkeys))))),
+		Args: append(fields.Values(), pkeys.Values()...),
+	}
+}
+
+// Update returns a query that updates a record of the same primary keys.
+func (b Builder) Update(r *sc.Record) *Query {
+	pkeys := r.Fields.Filter(sc.Key)
+	rest := r.Fields.Filter(sc.NonKey, sc.DbType, sc.NonNil)
+	if len(rest) == 0 {
+		rest = pkeys
+	}
+	return &Query{
+		Cmd:  b.bind(join("UPDATE", b.Quote(r.Name), "SET", b.assignList(rest), "WHERE", b.fieldEqualList(pkeys))),
+		Args: append(rest.Values(), pkeys.Values()...),
+	}
+}
+
+// Upsert returns a single query that inserts a record or updates it if the
+// primary keys exist, by the native syntax of the dialect.
+func (b Builder) Upsert(r *sc.Record) *Query {
+	fields := r.Fields.Filter(sc.DbType, sc.NonNil)
+	return b.UpsertRows(r.Name, names(fields), names(r.Fields.Filter(sc.Key)), [][]interface{}{fields.Values()})
+}
+
+// UpsertRows returns a single multi-row version of Upsert, each row contains
+// the values of columns cols, and keys are the primary keys. Without keys,
+// the rows are inserted as is.
+func (b Builder) UpsertRows(table string, cols, keys []string, rows [][]interface{}) *Query {
+	var rest []string
+	for _, c := range cols {
+		if !contains(keys, c) {
+			rest = append(rest, c)
+		}
+	}
+	row := brace(list(len(cols), ", ", func(int) string { return "?" }))
+	var args []interface{}
+	for _, r := range rows {
+		args = append(args, r...)
+	}
+	cmd := join("INSERT INTO", b.Quote(table), brace(b.list(cols)), "VALUES",
+		list(len(rows), ", ", func(int) string { return row }))
+	if len(keys) > 0 {
+		cmd = join(cmd, b.OnConflict(keys, rest))
+	}
+	return &Query{Cmd: b.bind(cmd), Args: args}
+}
+
+// Delete returns a query that deletes a record.
+func (b Builder) Delete(r *sc.Record) *Query {
+	fields := r.Fields.Filter(sc.DbType, sc.NonNil)
+	return &Query{
+		Cmd: b.bind(join("DELETE FROM", b.Quote(r.Name), "WHERE",
+			b.fieldEqualList(fields))),
+		Args: fields.Values(),
+	}
+}
+
+func (b Builder) fieldList(fs sc.Fields) string {
+	return list(len(fs), ", ", func(i int) string { return b.Quote(fs[i].Name) })
+}
+
+func (b Builder) list(names []string) string {
+	return list(len(names), ", ", func(i int) string { return b.Quote(names[i]) })
+}
+
+func (b Builder) fieldEqualList(fs sc.Fields) string {
+	return list(len(fs), " AND ",
+		func(i int) string {
+			return b.Quote(fs[i].Name) + "=?"
+		})
+}
+
+func (b Builder) assignList(fs sc.Fields) string {
+	return list(len(fs), ", ",
+		func(i int) string {
+			return b.Quote(fs[i].Name) + "=?"
+		})
+}
+
+// bind replaces the ? placeholders in s with the placeholders of the dialect.
+func (b Builder) bind(s string) string {
+	var buf bytes.Buffer
+	i := 1
+	for _, r := range s {
+		if r != '?' {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteString(b.Placeholder(i))
+			i++
+		}
+	}
+	return buf.String()
+}
+
+func placeholderList(fs sc.Fields) string {
+	return list(len(fs), ", ", func(int) string { return "?" })
+}
+
+func names(fs sc.Fields) []string {
+	ns := make([]string, len(fs))
+	for i, f := range fs {
+		ns[i] = f.Name
+	}
+	return ns
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func join(args ...string) string {
+	return strings.Join(args, " ")
+}
+
+func brace(s string) string {
+	return "(" + s + ")"
+}
+
+func list(cnt int, sep string, get func(i int) string) string {
+	var b bytes.Buffer
+	for i := 0; i < cnt-1; i++ {
+		b.WriteString(get(i))
+		b.WriteString(sep)
+	}
+	if cnt > 0 {
+		b.WriteString(get(cnt - 1))
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlstore
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hailiang/getgo/db/schema"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+type goldenType struct {
+	ID      int `sql:"pk"`
+	IDOther int `sql:"pk"`
+	SVal    string
+	IVal    int
+	PVal    *int
+	TVal    time.Time
+}
+
+type keyOnly struct {
+	ID int
+}
+
+type taggedType struct {
+	URL     string `sql:"name:url,pk"`
+	Title   string `sql:"index"`
+	Slug    string `sql:"unique"`
+	Body    string `sql:"type:VARCHAR(1000)"`
+	Views   *int   `sql:"default:0"`
+	Ignored string `sql:"-"`
+}
+
+func (taggedType) TableName() string { return "pages" }
+
+// TestGolden compares the queries built in each dialect with
+// testdata/<dialect>.golden. Run "go test -update" to regenerate them.
+func TestGolden(t *testing.T) {
+	dialects := []struct {
+		name string
+		d    Dialect
+	}{
+		{"postgres", Postgres},
+		{"mysql", MySQL},
+		{"sqlite", SQLite},
+	}
+	r := schema.NewRecord(&goldenType{ID: 1, IDOther: 2, SVal: "S", IVal: 9})
+	k := schema.NewRecord(&keyOnly{ID: 1})
+	for _, dialect := range dialects {
+		b := Builder{Dialect: dialect.d}
+		var buf bytes.Buffer
+		for _, q := range []*Query{
+			b.InsertIgnore(r), b.Update(r), b.Upsert(r), b.Delete(r),
+			b.Update(k), b.Upsert(k),
+			b.UpsertRows("t", []string{"id", "v"}, []string{"id"}, [][]interface{}{{1, "a"}, {2, "b"}}),
+			b.UpsertRows("t", []string{"v"}, nil, [][]interface{}{{"a"}, {"b"}}),
+		} {
+			fmt.Fprintf(&buf, "%s\n%v\n\n", q.Cmd, q.Args)
+		}
+		fmt.Fprintf(&buf, "%s\n\n", b.bind(b.TableExists()))
+		t0 := reflect.TypeOf(goldenType{})
+		for i := 0; i < t0.NumField(); i++ {
+			fmt.Fprintf(&buf, "%s %s\n", t0.Field(i).Name, dialect.d.Type(t0.Field(i).Type, i < 2))
+		}
+		table := schema.NewTable(&goldenType{})
+		tagged := schema.NewTable(&taggedType{})
+		for _, stmts := range [][]string{
+			b.CreateTable(table), b.AddColumn(table.Name, table.Columns[4]),
+			b.CreateTable(tagged), b.AddColumn(tagged.Name, tagged.Columns[4]),
+		} {
+			fmt.Fprintf(&buf, "\n%s\n", strings.Join(stmts, ";\n"))
+		}
+		q := b.Upsert(schema.NewRecord(&taggedType{URL: "u", Title: "t", Ignored: "x"}))
+		fmt.Fprintf(&buf, "\n%s\n%v\n", q.Cmd, q.Args)
+		file := filepath.Join("testdata", dialect.name+".golden")
+		if *update {
+			if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != string(want) {
+			t.Errorf("%s: expect\n%s\ngot\n%s", dialect.name, want, got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package sqlstore implements db.DB with a SQL database, parameterized by a
+Dialect, so that the SQL backends under db directory share the same query
+builder and upsert/delete semantics.
+*/
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hailiang/getgo/db"
+	sc "github.com/hailiang/getgo/db/schema"
+)
+
+// RetryNum is the retry number when a commit fails with an error that the
+// dialect regards as retryable (See Retrier).
+var RetryNum = 3
+
+// Retrier is an optional interface of a Dialect that tells if a failed
+// transaction can be retried, e.g. a serialization failure or a deadlock.
+type Retrier interface {
+	Retryable(err error) bool
+}
+
+// DB is a db.DB of a SQL database. On Commit, records are grouped by table and
+// written by multi-row upsert statements (or a Copier of the dialect), so
+// commits of concurrent transactions proceed in parallel. Records without a
+// primary key are always inserted.
+type DB struct {
+	// BatchSize is the maximum number of rows written by a single statement,
+	// BatchSize variable is used if it is 0.
+	BatchSize int
+
+	// AutoMigrate creates the missing tables and adds the missing columns of
+	// the records stored before committing them (See Migrate).
+	AutoMigrate bool
+
+	db       *sql.DB
+	b        Builder
+	migrated map[string]map[string]bool // migrated columns by table
+	mu       sync.Mutex                 // protects migrated
+}
+
+// Open opens a database by the driver and wraps it as a DB in dialect d.
+func Open(driverName, dataSourceName string, d Dialect) (*DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return New(db, d), nil
+}
+
+// New wraps an opened database as a DB in dialect d.
+func New(db *sql.DB, d Dialect) *DB {
+	return &DB{db: db, b: Builder{Dialect: d}}
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Begin implements the Begin method of db.DB interface.
+func (d *DB) Begin() (db.Tx, error) {
+	return &txImpl{db: d}, nil
+}
+
+type txImpl struct {
+	db *DB
+	ss []interface{}
+	ds []interface{}
+	mu sync.Mutex
+}
+
+func (t *txImpl) Store(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ss = append(t.ss, v)
+	return nil
+}
+
+func (t *txImpl) Delete(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ds = append(t.ds, v)
+	return nil
+}
+
+func (t *txImpl) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var err error
+	for i := 0; i <= RetryNum; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * 10 * time.Millisecond)
+		}
+		if err = t.commit(); err == nil || !t.db.retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (d *DB) retryable(err error) bool {
+	r, ok := d.b.Dialect.(Retrier)
+	return ok && r.Retryable(err)
+}
+
+func (t *txImpl) commit() error {
+	if t.db.AutoMigrate {
+		if err := t.db.autoMigrate(t.ss); err != nil {
+			return err
+		}
+	}
+	tx, err := t.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := t.db.storeRecords(tx, t.ss); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, v := range t.ds {
+		if err := t.db.b.deleteRecord(tx, v); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (t *txImpl) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ss = nil
+	t.ds = nil
+	return nil
+}
+
+// record returns s if it is a Record or creates a Record from it.
+func record(s interface{}) *sc.Record {
+	if r, ok := s.(*sc.Record); ok {
+		return r
+	}
+	return sc.NewRecord(s)
+}
+
+// deleteRecord deletes a record of a given primary key.
+func (b Builder) deleteRecord(tx Execer, s interface{}) error {
+	r := record(s)
+
+	// ignore nil record
+	if r == nil {
+		return nil
+	}
+
+	q := b.Delete(r)
+	_, err := q.Do(tx)
+	if err != nil {
+		return fmt.Errorf("%w -> %v", err, q)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+)
+
+// ExtractTask is an HTMLTask that extracts items declared by `getgo` struct
+// tags of T (See Extract) and stores a pointer to each of them.
+type ExtractTask[T any] struct {
+	Requester
+	Items string // CSS selector of the repeated item containers
+}
+
+// Handle implements the Handle method of HTMLTask interface.
+func (t ExtractTask[T]) Handle(root *query.Node, s Storer) error {
+	items, err := Extract[T](root, t.Items)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err := s.Store(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract finds the item containers matching the CSS selector items (or root
+// itself if items is empty) and populates a struct of type T from each of
+// them. A field is extracted when it has a `getgo` tag of semicolon separated
+// options:
+//
+//	css:<selector>  CSS selector of descendants of the container (See Select),
+//	                the container itself if neither css nor xpath is given
+//	xpath:<expr>    XPath 1.0 expression evaluated from the container (See XPath),
+//	                e.g. "xpath:.//a/@href", not to be used with css
+//	attr:<name>     the value of an attribute rather than the text
+//	html            the inner HTML rather than the text
+//
+// For example:
+//
+//	type Entry struct {
+//		Title string  `getgo:"css:a"`
+//		URL   string  `getgo:"css:a;attr:href"`
+//		Tags  *string `getgo:"css:span.tags"`
+//	}
+//
+// The value is converted to the kind of the field: string, numbers, bool or
+// time.Time. A pointer field is optional and left nil if nothing matches,
+// while an item is skipped if any other field does not match. A struct field
+// is extracted recursively within the matched node, and a slice field
+// collects all the matched nodes.
+func Extract[T any](root *query.Node, items string) ([]T, error) {
+	if root == nil {
+		return nil, nil
+	}
+	containers := []*html.Node{root.InternalNode()}
+	if items != "" {
+		sel, err := compileSelector(items)
+		if err != nil {
+			return nil, err
+		}
+		containers = selectAll(root.InternalNode(), sel)
+	}
+	var result []T
+	for _, c := range containers {
+		var v T
+		ok, err := extractStruct(reflect.ValueOf(&v).Elem(), c)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// extractTag is a parsed `getgo` tag of a struct field.
+type extractTag struct {
+	index int    // field index
+	name  string // field name
+	css   cascadia.Selector
+	xpath *XPath
+	attr  string
+	html  bool
+}
+
+func parseExtractTag(tag string) (*extractTag, error) {
+	t := &extractTag{}
+	for _, opt := range strings.Split(tag, ";") {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "":
+		case opt == "html":
+			t.html = true
+		case strings.HasPrefix(opt, "css:"):
+			sel, err := cascadia.Compile(strings.TrimSpace(opt[len("css:"):]))
+			if err != nil {
+				return nil, err
+			}
+			t.css = sel
+		case strings.HasPrefix(opt, "xpath:"):
+			x, err := CompileXPath(strings.TrimSpace(opt[len("xpath:"):]))
+			if err != nil {
+				return nil, err
+			}
+			t.xpath = x
+		case strings.HasPrefix(opt, "attr:"):
+			t.attr = strings.TrimSpace(opt[len("attr:"):])
+		default:
+			return nil, fmt.Errorf("unknown getgo tag option %q", opt)
+		}
+	}
+	if t.css != nil && t.xpath != nil {
+		return nil, errors.New("css and xpath cannot be used together")
+	}
+	return t, nil
+}
+
+// extractTags caches the parsed tags of struct types.
+var extractTags sync.Map // reflect.Type -> []*extractTag
+
+// structTags returns the parsed tags of the fields of struct type t.
+func structTags(t reflect.Type) ([]*extractTag, error) {
+	if tags, ok := extractTags.Load(t); ok {
+		return tags.([]*extractTag), nil
+	}
+	var tags []*extractTag
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tagStr, ok := f.Tag.Lookup("getgo")
+		if !ok || f.PkgPath != "" {
+			continue
+		}
+		tag, err := parseExtractTag(tagStr)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%s: %v", t, f.Name, err)
+		}
+		tag.index, tag.name = i, f.Name
+		tags = append(tags, tag)
+	}
+	extractTags.Store(t, tags)
+	return tags, nil
+}
+
+// extractStruct populates struct v from node n, and returns false if a
+// required field does not match.
+func extractStruct(v reflect.Value, n *html.Node) (bool, error) {
+	t := v.Type()
+	tags, err := structTags(t)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range tags {
+		nodes := []*html.Node{n}
+		if tag.css != nil {
+			nodes = selectAll(n, tag.css)
+		} else if tag.xpath != nil {
+			nodes = tag.xpath.nodes(n)
+		}
+		fv := v.Field(tag.index)
+		ok, err := extractField(fv, nodes, tag)
+		if err != nil {
+			return false, fmt.Errorf("%v.%s: %v", t, tag.name, err)
+		}
+		if !ok && fv.Kind() != reflect.Ptr && fv.Kind() != reflect.Slice {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// extractField sets field v from the matched nodes, and returns false if
+// nothing matches.
+func extractField(v reflect.Value, nodes []*html.Node, tag *extractTag) (bool, error) {
+	if len(nodes) == 0 {
+		return false, nil
+	}
+	switch {
+	case v.Kind() == reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), 0, len(nodes))
+		for _, n := range nodes {
+			e := reflect.New(v.Type().Elem()).Elem()
+			ok, err := extractField(e, []*html.Node{n}, tag)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				s = reflect.Append(s, e)
+			}
+		}
+		v.Set(s)
+		return s.Len() > 0, nil
+	case v.Kind() == reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		ok, err := extractField(p.Elem(), nodes, tag)
+		if ok && err == nil {
+			v.Set(p)
+		}
+		return ok, err
+	case v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}):
+		return extractStruct(v, nodes[0])
+	}
+	s, ok := nodeValue(nodes[0], tag)
+	if !ok {
+		return false, nil
+	}
+	return true, setString(v, s)
+}
+
+func nodeValue(n *html.Node, tag *extractTag) (string, bool) {
+	switch {
+	case tag.attr != "":
+		return attrOf(n, tag.attr), hasAttr(n, tag.attr)
+	case tag.html:
+		var b bytes.Buffer
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(&b, c); err != nil {
+				return "", false
+			}
+		}
+		return b.String(), true
+	}
+	return textOf(n), true
+}
@@ -0,0 +1,84 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+func TestExtract(t *testing.T) {
+	type author struct {
+		Name string `getgo:"css:.name"`
+	}
+	type entry struct {
+		Title  string   `getgo:"css:a"`
+		URL    string   `getgo:"css:a;attr:href"`
+		Tags   *string  `getgo:"css:span.tags"`
+		Votes  int      `getgo:"css:.votes"`
+		Words  []string `getgo:"css:li"`
+		Author author   `getgo:"css:.author"`
+		Link   string   `getgo:"xpath:.//a/@href"`
+		Count  int      `getgo:"xpath:count(.//b)"`
+	}
+	root, err := query.Parse(strings.NewReader(`
+	<div id="content">
+		<div class="blogtitle">
+			<a href="/a">A</a> <span class="tags">go</span>
+			<b class="votes">3</b>
+			<ul><li>x</li><li>y</li></ul>
+			<i class="author"><span class="name">Rob</span></i>
+		</div>
+		<div class="blogtitle">
+			<a href="/b">B</a>
+			<b class="votes">5</b>
+			<i class="author"><span class="name">Ken</span></i>
+		</div>
+		<div class="blogtitle"><span class="tags">no link</span></div>
+	</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Extract[entry](root, "#content .blogtitle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := "go"
+	expected := []entry{
+		{"A", "/a", &tags, 3, []string{"x", "y"}, author{"Rob"}, "/a", 1},
+		{"B", "/b", nil, 5, nil, author{"Ken"}, "/b", 1},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("expect %#v, got %#v", expected, entries)
+	}
+}
+
+func TestExtractTagError(t *testing.T) {
+	root, _ := query.Parse(strings.NewReader(`<div><a href="/a">A</a></div>`))
+	type both struct {
+		URL string `getgo:"css:a;xpath:.//a/@href"`
+	}
+	if _, err := Extract[both](root, "div"); err == nil {
+		t.Error("expect error for css and xpath used together")
+	}
+	type invalid struct {
+		URL string `getgo:"css:a[href"`
+	}
+	if _, err := Extract[invalid](root, "div"); err == nil {
+		t.Error("expect error for an invalid selector")
+	}
+	type link struct {
+		URL string `getgo:"css:a;attr:href"`
+	}
+	for i := 0; i < 2; i++ { // the second time uses the cached tags
+		links, err := Extract[link](root, "div")
+		if err != nil || len(links) != 1 || links[0].URL != "/a" {
+			t.Fatalf("unexpected links %v, %v", links, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Feed is a parsed RSS 2.0 or Atom feed.
+type Feed struct {
+	Title string
+	Link  string
+	Items []*FeedItem
+}
+
+// FeedItem is an RSS item or an Atom entry.
+type FeedItem struct {
+	Title      string
+	Link       string
+	GUID       string
+	Published  time.Time
+	Content    string
+	Enclosures []Enclosure
+}
+
+// Enclosure is a media object attached to a FeedItem.
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// ParseFeed parses an RSS 2.0 or Atom feed encoded in UTF-8.
+func ParseFeed(r io.Reader) (*Feed, error) {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(label string, r io.Reader) (io.Reader, error) {
+		return r, nil // already transcoded to UTF-8 by Storable.
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			var v rssXML
+			if err := d.DecodeElement(&v, &start); err != nil {
+				return nil, err
+			}
+			return v.feed(), nil
+		case "feed":
+			var v atomXML
+			if err := d.DecodeElement(&v, &start); err != nil {
+				return nil, err
+			}
+			return v.feed(), nil
+		}
+		return nil, errors.New("unknown feed format: " + start.Name.Local)
+	}
+}
+
+type rssXML struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+			Encoded     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			Enclosures  []struct {
+				URL    string `xml:"url,attr"`
+				Type   string `xml:"type,attr"`
+				Length int64  `xml:"length,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func (v *rssXML) feed() *Feed {
+	f := &Feed{Title: strings.TrimSpace(v.Channel.Title), Link: strings.TrimSpace(v.Channel.Link)}
+	for _, it := range v.Channel.Items {
+		item := &FeedItem{
+			Title:     strings.TrimSpace(it.Title),
+			Link:      strings.TrimSpace(it.Link),
+			GUID:      strings.TrimSpace(it.GUID),
+			Published: parseFeedTime(it.PubDate),
+			Content:   it.Encoded,
+		}
+		if item.Content == "" {
+			item.Content = it.Description
+		}
+		for _, e := range it.Enclosures {
+			item.Enclosures = append(item.Enclosures, Enclosure{e.URL, e.Type, e.Length})
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type atomXML struct {
+	Title   string     `xml:"title"`
+	Links   []atomLink `xml:"link"`
+	Entries []struct {
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		ID        string     `xml:"id"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Content   atomText   `xml:"content"`
+		Summary   atomText   `xml:"summary"`
+	} `xml:"entry"`
+}
+
+// atomText is an Atom text construct, whose type="xhtml" content is a child
+// element rather than text.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return t.Text
+}
+
+func (v *atomXML) feed() *Feed {
+	f := &Feed{Title: strings.TrimSpace(v.Title), Link: alternateLink(v.Links)}
+	for _, e := range v.Entries {
+		item := &FeedItem{
+			Title:     strings.TrimSpace(e.Title),
+			Link:      alternateLink(e.Links),
+			GUID:      strings.TrimSpace(e.ID),
+			Published: parseFeedTime(e.Published),
+			Content:   e.Content.String(),
+		}
+		if item.Published.IsZero() {
+			item.Published = parseFeedTime(e.Updated)
+		}
+		if item.Content == "" {
+			item.Content = e.Summary.String()
+		}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, Enclosure{l.Href, l.Type, l.Length})
+			}
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04 MST",
+	time.RFC3339Nano,
+}
+
+// parseFeedTime parses RFC 822 (RSS) and RFC 3339 (Atom) times, or returns a
+// zero time if the format is unknown.
+func parseFeedTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
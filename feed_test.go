@@ -0,0 +1,108 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRSS(t *testing.T) {
+	f, err := ParseFeed(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+	<title> Blog </title>
+	<link>http://example.com/</link>
+	<item>
+		<title>First</title>
+		<link>http://example.com/1</link>
+		<guid>1</guid>
+		<pubDate>Mon, 02 Jun 2014 15:04:05 +0000</pubDate>
+		<description>summary</description>
+		<content:encoded><![CDATA[<p>full</p>]]></content:encoded>
+		<enclosure url="http://example.com/1.mp3" type="audio/mpeg" length="123"/>
+	</item>
+	<item>
+		<title>Second</title>
+		<pubDate>02 Jun 14 15:04 GMT</pubDate>
+		<description>only summary</description>
+	</item>
+</channel>
+</rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Blog" || f.Link != "http://example.com/" || len(f.Items) != 2 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+	first, second := f.Items[0], f.Items[1]
+	if first.Content != "<p>full</p>" || first.GUID != "1" ||
+		!first.Published.Equal(time.Date(2014, 6, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected item %+v", first)
+	}
+	if len(first.Enclosures) != 1 || first.Enclosures[0] != (Enclosure{"http://example.com/1.mp3", "audio/mpeg", 123}) {
+		t.Fatalf("unexpected enclosures %+v", first.Enclosures)
+	}
+	if second.Content != "only summary" || second.Published.Year() != 2014 || second.Published.Minute() != 4 {
+		t.Fatalf("unexpected item %+v", second)
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := ParseFeed(strings.NewReader(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Atom</title>
+	<link rel="self" href="http://example.com/feed"/>
+	<link href="http://example.com/"/>
+	<entry>
+		<title>XHTML</title>
+		<link rel="alternate" href="http://example.com/1"/>
+		<link rel="enclosure" href="http://example.com/1.mp3" type="audio/mpeg" length="5"/>
+		<id>urn:1</id>
+		<published>2014-06-02T15:04:05Z</published>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>rich</p></div></content>
+	</entry>
+	<entry>
+		<title>Summary</title>
+		<id>urn:2</id>
+		<updated>2014-06-03T15:04:05+08:00</updated>
+		<summary type="html">&lt;b&gt;short&lt;/b&gt;</summary>
+	</entry>
+</feed>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Atom" || f.Link != "http://example.com/" || len(f.Items) != 2 {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+	first, second := f.Items[0], f.Items[1]
+	if first.Link != "http://example.com/1" || first.GUID != "urn:1" ||
+		!strings.Contains(first.Content, "<p>rich</p>") || len(first.Enclosures) != 1 {
+		t.Fatalf("unexpected entry %+v", first)
+	}
+	if second.Content != "<b>short</b>" || second.Published.Day() != 3 {
+		t.Fatalf("unexpected entry %+v", second)
+	}
+}
+
+func TestParseFeedTime(t *testing.T) {
+	want := time.Date(2014, 6, 2, 15, 4, 0, 0, time.UTC)
+	for _, s := range []string{
+		"Mon, 02 Jun 2014 15:04:00 +0000",
+		"Mon, 2 Jun 2014 15:04:00 GMT",
+		"2 Jun 2014 15:04:00 +0000",
+		"Mon, 02 Jun 14 15:04:00 +0000",
+		"02 Jun 14 15:04 +0000",
+		"2014-06-02T15:04:00Z",
+	} {
+		if got := parseFeedTime(s); !got.Equal(want) {
+			t.Errorf("%s: expect %v, got %v", s, want, got)
+		}
+	}
+	if got := parseFeedTime("someday"); !got.IsZero() {
+		t.Errorf("expect zero time, got %v", got)
+	}
+}
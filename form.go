@@ -0,0 +1,275 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Form encoding types.
+const (
+	FormURLEncoded = "application/x-www-form-urlencoded"
+	FormMultipart  = "multipart/form-data"
+	FormTextPlain  = "text/plain"
+)
+
+// Form is an HTML form extracted from a parsed page. Its fields can be modified
+// before it is turned into an HTTP request. A Form satisfies the Requester
+// interface so it can be embedded in the next task of a multi-step crawl.
+type Form struct {
+	Action  *url.URL            // Absolute URL the form is submitted to
+	Method  string              // GET or POST
+	Enctype string              // FormURLEncoded, FormMultipart or FormTextPlain
+	Values  url.Values          // Successful controls, including hidden fields
+	Options map[string][]string // Available option values of select controls
+	Buttons map[string]string   // Values of named submit buttons, see Click
+}
+
+// ParseForm extracts the first form within node n (or n itself if it is a
+// form). The action attribute is resolved against base, which is normally the
+// URL of the page, or used as is if base is nil. The method and enctype are
+// normalized as a browser does, so that the form can always be submitted. It
+// returns nil if no form is found.
+func ParseForm(n *query.Node, base *url.URL) *Form {
+	if n == nil {
+		return nil
+	}
+	formNode := findElement(n.InternalNode(), atom.Form)
+	if formNode == nil {
+		return nil
+	}
+	if base == nil {
+		base = &url.URL{}
+	}
+	f := &Form{
+		Action:  base,
+		Method:  strings.ToUpper(attrOf(formNode, "method")),
+		Enctype: strings.ToLower(attrOf(formNode, "enctype")),
+		Values:  make(url.Values),
+		Options: make(map[string][]string),
+		Buttons: make(map[string]string),
+	}
+	if action := attrOf(formNode, "action"); action != "" {
+		if u, err := base.Parse(action); err == nil {
+			f.Action = u
+		}
+	}
+	if f.Method != "POST" {
+		f.Method = "GET"
+	}
+	if f.Enctype != FormMultipart && f.Enctype != FormTextPlain {
+		f.Enctype = FormURLEncoded
+	}
+	walkElements(formNode, f.addControl)
+	return f
+}
+
+// Set sets the value of a field, replacing any existing values.
+func (f *Form) Set(name, value string) {
+	f.Values.Set(name, value)
+}
+
+// Del deletes the values of a field.
+func (f *Form) Del(name string) {
+	f.Values.Del(name)
+}
+
+// Click includes the value of the named submit button in the submission, as
+// if the form is submitted by clicking it.
+func (f *Form) Click(name string) error {
+	value, ok := f.Buttons[name]
+	if !ok {
+		return errors.New("no such submit button: " + name)
+	}
+	f.Values.Set(name, value)
+	return nil
+}
+
+// NewRequest creates an HTTP request that submits the form. A method other
+// than POST is submitted as GET, and an unknown enctype as FormURLEncoded.
+func (f *Form) NewRequest() (*http.Request, error) {
+	u := url.URL{}
+	if f.Action != nil {
+		u = *f.Action
+	}
+	if f.Method != "POST" {
+		u.RawQuery = f.Values.Encode()
+		return http.NewRequest("GET", u.String(), nil)
+	}
+	var body bytes.Buffer
+	contentType := f.Enctype
+	switch f.Enctype {
+	case FormMultipart:
+		w := multipart.NewWriter(&body)
+		for _, name := range sortedKeys(f.Values) {
+			for _, value := range f.Values[name] {
+				if err := w.WriteField(name, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		contentType = w.FormDataContentType()
+	case FormTextPlain:
+		for _, name := range sortedKeys(f.Values) {
+			for _, value := range f.Values[name] {
+				body.WriteString(name + "=" + value + "\r\n")
+			}
+		}
+	default:
+		contentType = FormURLEncoded
+		body.WriteString(f.Values.Encode())
+	}
+	req, err := http.NewRequest("POST", u.String(), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+// Request implements the Requester interface. A Form returned by ParseForm
+// never fails to create its request. Otherwise, a request without a URL is
+// returned on failure, which is rejected by the http.Client rather than
+// panicking the runner.
+func (f *Form) Request() *http.Request {
+	req, err := f.NewRequest()
+	if err != nil {
+		return &http.Request{Method: f.Method, URL: &url.URL{}, Header: make(http.Header)}
+	}
+	return req
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *Form) addControl(n *html.Node) {
+	name := attrOf(n, "name")
+	if name == "" || hasAttr(n, "disabled") {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Input:
+		switch strings.ToLower(attrOf(n, "type")) {
+		case "submit", "image":
+			f.Buttons[name] = attrOf(n, "value") // successful only if clicked.
+		case "button", "reset", "file":
+			// never successful, file is not supported.
+		case "checkbox", "radio":
+			if hasAttr(n, "checked") {
+				value := "on"
+				if hasAttr(n, "value") {
+					value = attrOf(n, "value")
+				}
+				f.Values.Add(name, value)
+			}
+		default:
+			f.Values.Add(name, attrOf(n, "value"))
+		}
+	case atom.Button:
+		if typ := strings.ToLower(attrOf(n, "type")); typ == "" || typ == "submit" {
+			f.Buttons[name] = attrOf(n, "value")
+		}
+	case atom.Textarea:
+		f.Values.Add(name, textOf(n))
+	case atom.Select:
+		var first *string
+		selected := false
+		walkElements(n, func(opt *html.Node) {
+			if opt.DataAtom != atom.Option {
+				return
+			}
+			value := textOf(opt)
+			if hasAttr(opt, "value") {
+				value = attrOf(opt, "value")
+			}
+			f.Options[name] = append(f.Options[name], value)
+			if first == nil {
+				first = &value
+			}
+			if hasAttr(opt, "selected") {
+				f.Values.Add(name, value)
+				selected = true
+			}
+		})
+		if !selected && first != nil && !hasAttr(n, "multiple") {
+			f.Values.Add(name, *first)
+		}
+	}
+}
+
+// findElement returns n or its first descendant element of atom a.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// walkElements calls visit on every descendant element of n in document order.
+func walkElements(n *html.Node, visit func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			visit(c)
+		}
+		walkElements(c, visit)
+	}
+}
+
+func attrOf(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func textOf(n *html.Node) string {
+	var b []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b = append(b, n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(strings.Join(b, ""))
+}
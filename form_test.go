@@ -0,0 +1,133 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+func parseTestForm(t *testing.T, src string, base *url.URL) *Form {
+	root, err := query.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := ParseForm(root, base)
+	if f == nil {
+		t.Fatal("expect a form")
+	}
+	return f
+}
+
+const testForm = `<html><body><form action="/search" method="%s" enctype="%s">
+<input type="hidden" name="token" value="abc">
+<input type="text" name="q" value="go">
+<input type="text" name="off" value="x" disabled>
+<input type="checkbox" name="c1" checked>
+<input type="checkbox" name="c2" value="yes" checked>
+<input type="checkbox" name="c3" value="no">
+<input type="radio" name="r" value="a">
+<input type="radio" name="r" value="b" checked>
+<select name="s1"><option>first</option><option value="2">second</option></select>
+<select name="s2"><option value="1">one</option><option value="2" selected>two</option></select>
+<select name="m" multiple><option>a</option></select>
+<textarea name="t"> text </textarea>
+<input type="submit" name="go" value="Go">
+<button name="more" value="More">More</button>
+<button type="reset" name="reset">Reset</button>
+</form></body></html>`
+
+func testFormSrc(method, enctype string) string {
+	return strings.Replace(strings.Replace(testForm, "%s", method, 1), "%s", enctype, 1)
+}
+
+func TestParseForm(t *testing.T) {
+	base, _ := url.Parse("http://example.com/a/b")
+	f := parseTestForm(t, testFormSrc("get", ""), base)
+	if f.Action.String() != "http://example.com/search" || f.Method != "GET" || f.Enctype != FormURLEncoded {
+		t.Fatalf("unexpected form %v %v %v", f.Action, f.Method, f.Enctype)
+	}
+	want := url.Values{
+		"token": {"abc"}, "q": {"go"}, "c1": {"on"}, "c2": {"yes"}, "r": {"b"},
+		"s1": {"first"}, "s2": {"2"}, "t": {"text"},
+	}
+	if !reflect.DeepEqual(f.Values, want) {
+		t.Fatalf("expect %v, got %v", want, f.Values)
+	}
+	if !reflect.DeepEqual(f.Options["s1"], []string{"first", "2"}) {
+		t.Fatalf("unexpected options %v", f.Options["s1"])
+	}
+	if !reflect.DeepEqual(f.Buttons, map[string]string{"go": "Go", "more": "More"}) {
+		t.Fatalf("unexpected buttons %v", f.Buttons)
+	}
+	if err := f.Click("go"); err != nil || f.Values.Get("go") != "Go" {
+		t.Fatalf("expect the clicked button submitted, %v", err)
+	}
+	if err := f.Click("reset"); err == nil {
+		t.Fatal("expect error for a reset button")
+	}
+
+	req := f.Request()
+	if req.Method != "GET" || req.URL.Query().Get("q") != "go" || req.URL.Query().Get("go") != "Go" {
+		t.Fatalf("unexpected request %v", req.URL)
+	}
+}
+
+func TestFormPost(t *testing.T) {
+	f := parseTestForm(t, testFormSrc("post", ""), nil)
+	if f.Action.String() != "/search" {
+		t.Fatalf("expect the action as is without a base, got %v", f.Action)
+	}
+	req := f.Request()
+	if req.Method != "POST" || req.Header.Get("Content-Type") != FormURLEncoded {
+		t.Fatalf("unexpected request %v %v", req.Method, req.Header)
+	}
+	req.ParseForm()
+	if req.PostForm.Get("token") != "abc" || req.PostForm.Get("s2") != "2" {
+		t.Fatalf("unexpected post form %v", req.PostForm)
+	}
+}
+
+func TestFormMultipart(t *testing.T) {
+	f := parseTestForm(t, testFormSrc("POST", "multipart/form-data"), nil)
+	req := f.Request()
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != FormMultipart {
+		t.Fatalf("unexpected content type %v, %v", mediaType, err)
+	}
+	form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(form.Value["q"], []string{"go"}) || !reflect.DeepEqual(form.Value["c2"], []string{"yes"}) {
+		t.Fatalf("unexpected multipart form %v", form.Value)
+	}
+}
+
+func TestFormTextPlain(t *testing.T) {
+	f := parseTestForm(t, `<form method="post" enctype="text/plain"><input name="b" value="2"><input name="a" value="1"></form>`, nil)
+	req := f.Request()
+	body, _ := ioutil.ReadAll(req.Body)
+	if req.Header.Get("Content-Type") != FormTextPlain || string(body) != "a=1\r\nb=2\r\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestFormUnknownEnctype(t *testing.T) {
+	f := parseTestForm(t, `<form method="post" enctype="x-unknown"><input name="a" value="1"></form>`, nil)
+	if f.Enctype != FormURLEncoded {
+		t.Fatalf("expect the default enctype, got %v", f.Enctype)
+	}
+	if req := (&Form{Method: "POST", Enctype: "x-unknown"}).Request(); req == nil {
+		t.Fatal("expect a request")
+	}
+}
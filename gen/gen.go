@@ -0,0 +1,426 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package gen generates the skeleton of an HTMLTask from a saved page and a few
+example values to extract. It infers the repeating item container and the path
+of each field within it, and emits a Go struct and task in the style of
+examples/goblog.
+*/
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+)
+
+// Field is a field to extract, with example values of the first items in
+// document order, one value per item.
+type Field struct {
+	Name     string
+	Examples []string
+}
+
+// Config configures the generated code.
+type Config struct {
+	Name   string // Name of the record struct, e.g. golangBlogEntry
+	URL    string // URL of the page
+	Fields []Field
+}
+
+// match is where an example value is found.
+type match struct {
+	node *html.Node
+	attr string // attribute name, or empty for the text
+}
+
+// Generate returns the formatted Go source of a main package that extracts
+// the fields from pages like root.
+func Generate(root *query.Node, c *Config) ([]byte, error) {
+	if len(c.Fields) == 0 {
+		return nil, errors.New("at least one field is required")
+	}
+	if c.Name == "" {
+		c.Name = "entry"
+	}
+	// matches[i][j] is the match of field j in item i.
+	var matches [][]match
+	for j, f := range c.Fields {
+		if len(f.Examples) == 0 {
+			return nil, fmt.Errorf("field %s: no example value", f.Name)
+		}
+		for i, example := range f.Examples {
+			m, ok := find(root.InternalNode(), example)
+			if !ok {
+				return nil, fmt.Errorf("field %s: example %q not found", f.Name, example)
+			}
+			for len(matches) <= i {
+				matches = append(matches, make([]match, len(c.Fields)))
+			}
+			matches[i][j] = m
+		}
+	}
+	container, err := inferContainer(matches)
+	if err != nil {
+		return nil, err
+	}
+	parent := container.Parent
+	class := firstClass(container)
+	containers := children(parent, container.Data, class)
+
+	d := &data{Config: c, Parent: Chain(parent), ContainerClass: class}
+	names := make(map[string]bool)
+	for j, f := range c.Fields {
+		name := goName(f.Name)
+		if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+			return nil, fmt.Errorf("field %s: not a valid Go name", f.Name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("field %s: duplicate Go name %s", f.Name, name)
+		}
+		names[name] = true
+		m := matches[0][j]
+		if m.node == nil {
+			m = firstMatch(matches, j)
+		}
+		fd := fieldData{
+			Name:  name,
+			Var:   varName(f.Name),
+			Chain: "item" + relativeChain(container, m.node) + valueMethod(m.attr),
+		}
+		// a field missing in any container is optional.
+		for _, c := range containers {
+			if n := resolve(c, steps(container, m.node)); n == nil ||
+				(m.attr != "" && !hasAttr(n, m.attr)) {
+				fd.Optional = true
+				break
+			}
+		}
+		d.Fields = append(d.Fields, fd)
+	}
+	var required []string
+	for _, f := range d.Fields {
+		if !f.Optional {
+			required = append(required, f.Var+" != nil")
+		}
+	}
+	d.Cond = strings.Join(required, " && ")
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
+
+func firstMatch(matches [][]match, j int) match {
+	for _, ms := range matches {
+		if ms[j].node != nil {
+			return ms[j]
+		}
+	}
+	return match{}
+}
+
+// inferContainer returns the item container: the LCA of the matches of the
+// first item if there is only one, otherwise the ancestor of it that is a
+// direct child of the LCA of all the items.
+func inferContainer(matches [][]match) (*html.Node, error) {
+	var first, all []*html.Node
+	for i, ms := range matches {
+		for _, m := range ms {
+			if m.node != nil {
+				if i == 0 {
+					first = append(first, m.node)
+				}
+				all = append(all, m.node)
+			}
+		}
+	}
+	item := lca(first)
+	if len(matches) == 1 {
+		if item.Parent == nil {
+			return nil, errors.New("cannot infer the item container")
+		}
+		return item, nil
+	}
+	common := lca(all)
+	for item != nil && item.Parent != common {
+		item = item.Parent
+	}
+	if item == nil {
+		return nil, errors.New("examples of the first item span multiple items")
+	}
+	return item, nil
+}
+
+func lca(nodes []*html.Node) *html.Node {
+	common := nodes[0]
+	for _, n := range nodes[1:] {
+		for !isAncestor(common, n) {
+			common = common.Parent
+		}
+	}
+	return common
+}
+
+func isAncestor(a, n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n == a {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the first element whose text or attribute equals the example.
+func find(root *html.Node, example string) (m match, ok bool) {
+	example = normalize(example)
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.TextNode && normalize(n.Data) == example {
+			m, ok = match{node: n.Parent}, true
+		} else if n.Type == html.ElementNode {
+			for _, a := range n.Attr {
+				if a.Key != "class" && a.Key != "id" && normalize(a.Val) == example {
+					m, ok = match{node: n, attr: a.Key}, true
+					break
+				}
+			}
+		}
+		return !ok
+	})
+	if !ok {
+		// text split into multiple nodes, e.g. by <b>.
+		walk(root, func(n *html.Node) bool {
+			if n.Type == html.ElementNode && normalize(text(n)) == example {
+				m, ok = match{node: n}, true // keep the deepest one
+			}
+			return true
+		})
+	}
+	return
+}
+
+// step is a single html-query method call selecting the first descendant
+// element of a tag, optionally of a class.
+type step struct {
+	tag, class string
+}
+
+func (s step) String() string {
+	if s.class != "" {
+		return fmt.Sprintf(".%s(_Class(%q))", tagMethod(s.tag), s.class)
+	}
+	return fmt.Sprintf(".%s()", tagMethod(s.tag))
+}
+
+func (s step) matches(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Data == s.tag &&
+		(s.class == "" || hasClass(n, s.class))
+}
+
+// steps returns the steps from container to n, the shortest suffix of the
+// path that still resolves to n.
+func steps(container, n *html.Node) []step {
+	var path []step
+	for p := n; p != container && p != nil; p = p.Parent {
+		path = append([]step{{p.Data, firstClass(p)}}, path...)
+	}
+	for k := 1; k < len(path); k++ {
+		if resolve(container, path[len(path)-k:]) == n {
+			return path[len(path)-k:]
+		}
+	}
+	return path
+}
+
+func resolve(n *html.Node, ss []step) *html.Node {
+	for _, s := range ss {
+		var found *html.Node
+		walk(n, func(c *html.Node) bool {
+			if s.matches(c) {
+				found = c
+			}
+			return found == nil
+		})
+		if found == nil {
+			return nil
+		}
+		n = found
+	}
+	return n
+}
+
+func relativeChain(container, n *html.Node) string {
+	var b bytes.Buffer
+	for _, s := range steps(container, n) {
+		b.WriteString(s.String())
+	}
+	return b.String()
+}
+
+// Chain returns a suggested html-query method chain to reach node n from the
+// root, anchored at the nearest ancestor with an id.
+func Chain(n *html.Node) string {
+	var path []*html.Node
+	for p := n; p != nil && p.Type != html.DocumentNode; p = p.Parent {
+		path = append([]*html.Node{p}, path...)
+		if attr(p, "id") != "" {
+			break
+		}
+	}
+	chain := "root"
+	for _, p := range path {
+		switch {
+		case p.Type == html.TextNode:
+			chain += ".Text()"
+		case p.Type != html.ElementNode:
+		case attr(p, "id") != "":
+			chain += fmt.Sprintf(".%s(_Id(%q))", tagMethod(p.Data), attr(p, "id"))
+		case firstClass(p) != "":
+			chain += step{p.Data, firstClass(p)}.String()
+		case (p.Data == "html" || p.Data == "body") && p != n:
+		default:
+			chain += step{p.Data, ""}.String()
+		}
+	}
+	return chain
+}
+
+func valueMethod(attr string) string {
+	switch attr {
+	case "":
+		return ".Text()"
+	case "href":
+		return ".Href()"
+	}
+	return fmt.Sprintf(".Attr(%q)", attr)
+}
+
+// children returns the child elements of n of a tag and a class.
+func children(n *html.Node, tag, class string) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if (step{tag, class}).matches(c) {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+func tagMethod(tag string) string {
+	if tag == "a" {
+		return "Ahref"
+	}
+	return strings.ToUpper(tag[:1]) + tag[1:]
+}
+
+var initialisms = map[string]bool{"id": true, "url": true, "uri": true, "html": true, "http": true, "ip": true}
+
+// goName converts a field name like "post_url" to an exported Go name PostURL.
+func goName(s string) string {
+	var b bytes.Buffer
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+		} else {
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
+
+// reserved are the identifiers used by the template, which a field variable
+// must not shadow.
+var reserved = map[string]bool{
+	"t": true, "s": true, "err": true, "root": true, "item": true, "store": true,
+	"getReq": true, "checkError": true, "main": true, "_Id": true, "_Class": true,
+	"fmt": true, "http": true, "getgo": true, "util": true, "query": true, "expr": true,
+}
+
+// varName returns the name of the local variable of a field, suffixed with
+// "Val" if it is a Go keyword, a predeclared identifier or used by the
+// template.
+func varName(s string) string {
+	name := goName(s)
+	if strings.ToUpper(name) == name {
+		name = strings.ToLower(name)
+	} else {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if token.IsKeyword(name) || types.Universe.Lookup(name) != nil || reserved[name] {
+		name += "Val"
+	}
+	return name
+}
+
+func firstClass(n *html.Node) string {
+	if fs := strings.Fields(attr(n, "class")); len(fs) > 0 {
+		return fs[0]
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func text(n *html.Node) string {
+	var b bytes.Buffer
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		return true
+	})
+	return b.String()
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// walk visits the descendants of n in document order until visit returns
+// false.
+func walk(n *html.Node, visit func(*html.Node) bool) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !visit(c) || !walk(c, visit) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+func TestGenerate(t *testing.T) {
+	root, err := query.Parse(strings.NewReader(`
+	<div id="content">
+		<h1>The Go Blog</h1>
+		<p class="blogtitle">
+			<a href="/first">First post</a>
+			<span class="date">1 Jan</span>
+			<span class="tags">go</span>
+		</p>
+		<p class="blogtitle">
+			<a href="/second">Second post</a>
+			<span class="date">2 Jan</span>
+		</p>
+	</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(root, &Config{
+		Name: "golangBlogEntry",
+		URL:  "http://blog.golang.org/index?q=100%25",
+		Fields: []Field{
+			{"title", []string{"First post", "Second post"}},
+			{"url", []string{"/first", "/second"}},
+			{"tags", []string{"go"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"\tTitle string\n",
+		`return getReq("%s", "http://blog.golang.org/index?q=100%25")`,
+		"\tURL   string\n",
+		"\tTags  *string\n",
+		`root.Div(_Id("content")).Children(_Class("blogtitle")).For(func(item *query.Node) {`,
+		`title := item.Ahref().Text()`,
+		`url := item.Ahref().Href()`,
+		`tags := item.Span(_Class("tags")).Text()`,
+		`if title != nil && url != nil {`,
+		`store(&golangBlogEntry{Title: *title, URL: *url, Tags: tags}, s, &err)`,
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("expect %q in\n%s", expected, src)
+		}
+	}
+}
+
+func TestGenerateNames(t *testing.T) {
+	root, err := query.Parse(strings.NewReader(`<html><body>
+		<p><a href="/1">one</a> <i>x</i> <b>go</b></p>
+		<p><a href="/2">two</a> <i>y</i> <b>js</b></p>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(root, &Config{
+		Fields: []Field{
+			{"err", []string{"one", "two"}},
+			{"type", []string{"x", "y"}},
+			{"s", []string{"go", "js"}},
+			{"item", []string{"/1", "/2"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`root.Body().Children().For(func(item *query.Node) {`,
+		`errVal := item.Ahref().Text()`,
+		`typeVal := item.I().Text()`,
+		`sVal := item.B().Text()`,
+		`itemVal := item.Ahref().Href()`,
+		`store(&entry{Err: *errVal, Type: *typeVal, S: *sVal, Item: *itemVal}, s, &err)`,
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("expect %q in\n%s", expected, src)
+		}
+	}
+
+	for _, fields := range [][]Field{
+		{{"1st", []string{"one"}}},
+		{{"post_url", []string{"one"}}, {"postURL", []string{"x"}}},
+	} {
+		if _, err := Generate(root, &Config{Fields: fields}); err == nil {
+			t.Errorf("expect an error for fields %v", fields)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gen
+
+import (
+	"text/template"
+)
+
+type data struct {
+	*Config
+	Parent         string // method chain to the parent of the item containers
+	ContainerClass string
+	Fields         []fieldData
+	Cond           string // condition of required fields to store an item
+}
+
+type fieldData struct {
+	Name     string
+	Var      string
+	Chain    string
+	Optional bool
+}
+
+var tmpl = template.Must(template.New("task").Parse(`package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/util"
+	"github.com/hailiang/html-query"
+	"github.com/hailiang/html-query/expr"
+)
+
+func main() {
+	util.Run({{.Name}}Task{})
+}
+
+var (
+	_Id    = expr.Id
+	_Class = expr.Class
+)
+
+// {{.Name}} represents a record for storing an item.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{if .Optional}}*{{end}}string
+{{- end}}
+}
+
+// {{.Name}}Task retrieves the items of {{.URL}}.
+type {{.Name}}Task struct {
+	// Task variables, e.g. page number
+}
+
+func (t {{.Name}}Task) Request() *http.Request {
+	return getReq("%s", {{printf "%q" .URL}})
+}
+
+func (t {{.Name}}Task) Handle(root *query.Node, s getgo.Storer) (err error) {
+	{{.Parent}}.Children({{if .ContainerClass}}_Class({{printf "%q" .ContainerClass}}){{end}}).For(func(item *query.Node) {
+	{{- range .Fields}}
+		{{.Var}} := {{.Chain}}
+	{{- end}}
+		{{if .Cond}}if {{.Cond}} {
+		{{end -}}
+			store(&{{.Name}}{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Name}}: {{if not $f.Optional}}*{{end}}{{$f.Var}}{{end -}} }, s, &err)
+		{{- if .Cond}}
+		}{{end}}
+	})
+	return
+}
+
+func getReq(template string, args ...interface{}) *http.Request {
+	req, err := http.NewRequest("GET", fmt.Sprintf(template, args...), nil)
+	checkError(err)
+	return req
+}
+
+func store(v interface{}, s getgo.Storer, err *error) {
+	if *err == nil {
+		*err = s.Store(v)
+	}
+}
+
+func checkError(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+`))
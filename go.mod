@@ -0,0 +1,14 @@
+module github.com/hailiang/getgo
+
+go 1.23.0
+
+require (
+	github.com/andybalholm/cascadia v1.3.3
+	github.com/antchfx/xpath v1.3.5
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/lib/pq v1.9.0
+	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v2 v2.4.0
+)
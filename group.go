@@ -56,6 +56,10 @@ func (t *groupTx) Store(v interface{}) error {
 	return t.tx.Store(v)
 }
 
+func (t *groupTx) Delete(v interface{}) error {
+	return deleteFrom(t.tx, v)
+}
+
 func (t *groupTx) done(result bool) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -78,11 +82,18 @@ func (t *groupTx) Rollback() error {
 	return t.done(false)
 }
 
-// Add either HTMLTask, TextTask or StorableTask to TaskGroup.
+// Add either HTMLTask, FeedTask, XMLTask, CSVTask, TextTask or StorableTask to
+// TaskGroup.
 func addTask(task interface{}, g *TaskGroup) {
 	switch t := task.(type) {
 	case HTMLTask:
 		g.Add(Storable{Text{t}})
+	case FeedTask:
+		g.Add(Storable{FeedText{t}})
+	case XMLTask:
+		g.Add(Storable{XMLText{t}})
+	case CSVTask:
+		g.Add(Storable{CSVText{t}})
 	case TextTask:
 		g.Add(Storable{t})
 	case StorableTask:
@@ -5,6 +5,7 @@
 package getgo
 
 import (
+	"encoding/xml"
 	"io"
 	"net/http"
 
@@ -46,6 +47,33 @@ type HTMLTask interface {
 	Handle(root *query.Node, s Storer) error
 }
 
+// FeedTask is a task that handles the items of an RSS or Atom feed.
+type FeedTask interface {
+	Requester
+	Handle(feed *Feed, s Storer) error
+}
+
+// XMLTask is a task that reads an XML response with an xml.Decoder, either by
+// decoding it into a typed struct or by walking its tokens for very large
+// documents.
+type XMLTask interface {
+	Requester
+	Handle(d *xml.Decoder, s Storer) error
+}
+
+// CSVTask is a task that handles a CSV or TSV response row by row.
+type CSVTask interface {
+	Requester
+	Handle(row *CSVRow, s Storer) error
+}
+
+// JSONTask is a task that handles a JSON response decoded into a value of type
+// T. It is adapted to a StorableTask by the JSON adapter.
+type JSONTask[T any] interface {
+	Requester
+	Handle(v T, s Storer) error
+}
+
 // Requester is the interface that returns an HTTP request by Request method.
 // The Request method must be implemented to allow repeated calls.
 type Requester interface {
@@ -57,6 +85,12 @@ type Storer interface {
 	Store(v interface{}) error
 }
 
+// Deleter provides the Delete method to delete an object. A Tx may optionally
+// implement it.
+type Deleter interface {
+	Delete(v interface{}) error
+}
+
 // Tx is a transaction interface that provides methods for storing objects,
 // commit or rollback changes. Notice that there is no Delete method defined.
 // Tx's implementation must allow concurrent use.
@@ -0,0 +1,91 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// JSONOptions controls how the JSON adapter decodes a response.
+type JSONOptions struct {
+	// DisallowUnknownFields makes decoding fail when an object has a key that
+	// does not match any field of the destination, to catch API drift.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes a number into a json.Number instead of a float64.
+	UseNumber bool
+
+	// StrictContentType requires the Content-Type of the response to be
+	// application/json or a +json type.
+	StrictContentType bool
+}
+
+// JSONOptioner is optionally implemented by a JSONTask to customize decoding.
+type JSONOptioner interface {
+	JSONOptions() JSONOptions
+}
+
+// JSON is an adapter that converts a JSONTask to a StorableTask. Since the type
+// parameter cannot be inferred from a task, it must be given explicitly, e.g.
+// getgo.Run(runner, tx, getgo.JSON[*Item]{itemTask{}}).
+type JSON[T any] struct {
+	JSONTask[T]
+}
+
+func (j JSON[T]) wrapped() interface{} { return j.JSONTask }
+
+// Handle implements the Handle method of StorableTask interface.
+func (j JSON[T]) Handle(resp *http.Response, s Storer) error {
+	var opts JSONOptions
+	if o, ok := j.JSONTask.(JSONOptioner); ok {
+		opts = o.JSONOptions()
+	}
+	return Storable{jsonText[T]{j.JSONTask, opts}}.Handle(resp, s)
+}
+
+// jsonText converts a JSONTask to a TextTask.
+type jsonText[T any] struct {
+	JSONTask[T]
+	opts JSONOptions
+}
+
+func (t jsonText[T]) wrapped() interface{} { return t.JSONTask }
+
+// checkContentType is called by Storable after the status code is accepted,
+// so that an error page is reported as a StatusError instead.
+func (t jsonText[T]) checkContentType(ct string) error {
+	if t.opts.StrictContentType && !isJSONContentType(ct) {
+		return errors.New("unexpected Content-Type: " + ct)
+	}
+	return nil
+}
+
+func (t jsonText[T]) Handle(r io.Reader, s Storer) error {
+	d := json.NewDecoder(r)
+	if t.opts.DisallowUnknownFields {
+		d.DisallowUnknownFields()
+	}
+	if t.opts.UseNumber {
+		d.UseNumber()
+	}
+	var v T
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	return t.JSONTask.Handle(v, s)
+}
+
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,104 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type jsonItem struct {
+	ID    int         `json:"id"`
+	Title string      `json:"title"`
+	Score interface{} `json:"score"`
+}
+
+type jsonItemTask struct {
+	opts JSONOptions
+	gone bool
+}
+
+func (t *jsonItemTask) Request() *http.Request { return getReq("http://example.com/items") }
+
+func (t *jsonItemTask) JSONOptions() JSONOptions { return t.opts }
+
+func (t *jsonItemTask) StatusPolicy() StatusPolicy {
+	return StatusPolicy{Gone: []int{http.StatusGone}}
+}
+
+func (t *jsonItemTask) HandleGone(d Deleter) error {
+	t.gone = true
+	return d.Delete(&jsonItem{ID: 1})
+}
+
+func (t *jsonItemTask) Handle(items []jsonItem, s Storer) error {
+	for i := range items {
+		if err := s.Store(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestJSON(t *testing.T) {
+	tx := &memTx{}
+	task := JSON[[]jsonItem]{&jsonItemTask{}}
+	resp := newResponse(200, "application/json", `[{"id":1,"title":"a","score":1.5},{"id":2,"title":"b"}]`)
+	if err := task.Handle(resp, tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.stored) != 2 {
+		t.Fatalf("expect 2 items, got %d", len(tx.stored))
+	}
+	if item := tx.stored[0].(*jsonItem); item.ID != 1 || item.Title != "a" || item.Score != 1.5 {
+		t.Fatalf("unexpected item %+v", item)
+	}
+}
+
+func TestJSONOptions(t *testing.T) {
+	tx := &memTx{}
+	task := &jsonItemTask{opts: JSONOptions{UseNumber: true}}
+	if err := (JSON[[]jsonItem]{task}).Handle(newResponse(200, "text/plain", `[{"id":1,"score":12345678901234567890}]`), tx); err != nil {
+		t.Fatal(err)
+	}
+	if score := tx.stored[0].(*jsonItem).Score; score != json.Number("12345678901234567890") {
+		t.Fatalf("expect a json.Number, got %#v", score)
+	}
+
+	task.opts = JSONOptions{DisallowUnknownFields: true}
+	if err := (JSON[[]jsonItem]{task}).Handle(newResponse(200, "application/json", `[{"id":1,"extra":true}]`), tx); err == nil {
+		t.Fatal("expect an error for an unknown field")
+	}
+
+	task.opts = JSONOptions{StrictContentType: true}
+	for _, ct := range []string{"application/json; charset=utf-8", "application/ld+json"} {
+		if err := (JSON[[]jsonItem]{task}).Handle(newResponse(200, ct, `[]`), tx); err != nil {
+			t.Fatalf("%s: %v", ct, err)
+		}
+	}
+	err := (JSON[[]jsonItem]{task}).Handle(newResponse(200, "text/html", `<html></html>`), tx)
+	if _, ok := err.(*StatusError); ok || err == nil {
+		t.Fatalf("expect a Content-Type error, got %v", err)
+	}
+}
+
+func TestJSONStatus(t *testing.T) {
+	task := &jsonItemTask{opts: JSONOptions{StrictContentType: true}}
+
+	// an HTML error page is reported by its status rather than Content-Type.
+	err := (JSON[[]jsonItem]{task}).Handle(newResponse(404, "text/html", `<h1>Not Found</h1>`), &memTx{})
+	if se, ok := err.(*StatusError); !ok || se.StatusCode != 404 || se.Body != `<h1>Not Found</h1>` {
+		t.Fatalf("expect a 404 StatusError, got %v", err)
+	}
+
+	tx := &memTx{}
+	if err := (JSON[[]jsonItem]{task}).Handle(newResponse(410, "text/html", `<h1>Gone</h1>`), tx); err != nil {
+		t.Fatal(err)
+	}
+	if !task.gone || len(tx.deleted) != 1 {
+		t.Fatalf("expect the GoneHandler to be called, got %v", tx.deleted)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 )
@@ -74,7 +75,7 @@ func (l *HTTPLogger) log(req *http.Request) {
 	sec := time.Now().Sub(l.startTime).Seconds()
 	reqSpeed := int(float64(l.totalReqCount) / sec)
 	kbSpeed := int(float64(l.totalByteCount) / sec / 1000)
-	fmt.Printf("[%dKB, %d, %dKB] %s\n",
+	fmt.Fprintf(os.Stderr, "[%dKB, %d, %dKB] %s\n",
 		int(l.avgByteCounter.PerSecond()/1000), reqSpeed, kbSpeed, req.URL)
 }
 
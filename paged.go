@@ -0,0 +1,191 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/hailiang/html-query"
+)
+
+// NextPageFunc extracts the request of the next page from the parsed current
+// page, req is the request of the current page. It returns nil if there is no
+// next page.
+type NextPageFunc func(root *query.Node, req *http.Request) *http.Request
+
+// NextHref returns a NextPageFunc that follows the link returned by href,
+// resolved against the URL of the current page.
+func NextHref(href func(root *query.Node) *string) NextPageFunc {
+	return func(root *query.Node, req *http.Request) *http.Request {
+		link := href(root)
+		if link == nil || *link == "" {
+			return nil
+		}
+		u, err := req.URL.Parse(*link)
+		if err != nil {
+			return nil
+		}
+		next, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil
+		}
+		return next
+	}
+}
+
+// PagedTask runs an HTMLTask or a TextTask over a sequence of pages. The
+// pages are either generated from PageURL and page numbers, or followed by
+// NextPage starting from the request of Task (HTMLTask only). Pagination stops
+// when a page stores nothing, NextPage returns nil or a page visited already,
+// MaxPages is reached or a page fails.
+type PagedTask struct {
+	Task      interface{}  // HTMLTask or TextTask that handles every page
+	NextPage  NextPageFunc // Next page extractor, used when PageURL is empty
+	PageURL   string       // URL template with a %d verb for the page number
+	FirstPage int          // Number of the first page when PageURL is used
+	MaxPages  int          // Maximum number of pages, 0 means unlimited
+}
+
+// Run runs the pages one after another by runner. All pages are stored within
+// a single transaction tx, which is committed if all pages succeed or rolled
+// back otherwise.
+func (t *PagedTask) Run(runner Runner, tx Tx) error {
+	if t.PageURL == "" && t.NextPage == nil {
+		return errors.New("either PageURL or NextPage must be provided")
+	}
+	req, err := t.pageRequest(t.FirstPage, nil)
+	if err != nil {
+		return err
+	}
+	ok := true
+	visited := make(map[string]bool)
+	for i := 0; req != nil && (t.MaxPages <= 0 || i < t.MaxPages); i++ {
+		u := *req.URL
+		u.Fragment = ""
+		if visited[u.String()] {
+			break // a link back to a page visited, e.g. the last page itself.
+		}
+		visited[u.String()] = true
+		p := &page{req: req, tx: tx, done: make(chan struct{})}
+		if err := t.runPage(runner, p); err != nil {
+			tx.Rollback()
+			return err
+		}
+		<-p.done
+		if !p.ok {
+			ok = false
+			break
+		}
+		if p.cnt == 0 {
+			break
+		}
+		if req, err = t.pageRequest(t.FirstPage+i+1, p.next); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if ok {
+		return tx.Commit()
+	}
+	return tx.Rollback()
+}
+
+func (t *PagedTask) pageRequest(n int, next *http.Request) (*http.Request, error) {
+	if t.PageURL != "" {
+		return http.NewRequest("GET", fmt.Sprintf(t.PageURL, n), nil)
+	}
+	if next != nil {
+		return next, nil
+	}
+	if n == t.FirstPage {
+		if r, ok := t.Task.(Requester); ok {
+			return r.Request(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (t *PagedTask) runPage(runner Runner, p *page) error {
+	switch task := t.Task.(type) {
+	case HTMLTask:
+		return runner.Run(Atomized{Storable{Text{htmlPage{task, p, t.NextPage}}}, p})
+	case TextTask:
+		if t.PageURL == "" {
+			return errors.New("NextPage requires an HTMLTask")
+		}
+		return runner.Run(Atomized{Storable{textPage{task, p}}, p})
+	}
+	return errors.New("paged task is unexpected type")
+}
+
+// page records the result of a single page and forwards stored objects to
+// the transaction of the PagedTask.
+type page struct {
+	req  *http.Request
+	next *http.Request
+	cnt  int
+	ok   bool
+	tx   Tx
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+}
+
+func (p *page) Store(v interface{}) error {
+	p.mu.Lock()
+	p.cnt++
+	p.mu.Unlock()
+	return p.tx.Store(v)
+}
+
+func (p *page) Delete(v interface{}) error {
+	return deleteFrom(p.tx, v)
+}
+
+func (p *page) Commit() error {
+	p.once.Do(func() { p.ok = true; close(p.done) })
+	return nil
+}
+
+func (p *page) Rollback() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+type htmlPage struct {
+	HTMLTask
+	p    *page
+	next NextPageFunc
+}
+
+func (h htmlPage) wrapped() interface{} { return h.HTMLTask }
+
+func (h htmlPage) Request() *http.Request {
+	return h.p.req
+}
+
+func (h htmlPage) Handle(root *query.Node, s Storer) error {
+	if err := h.HTMLTask.Handle(root, s); err != nil {
+		return err
+	}
+	if h.next != nil {
+		h.p.next = h.next(root, h.p.req)
+	}
+	return nil
+}
+
+type textPage struct {
+	TextTask
+	p *page
+}
+
+func (t textPage) wrapped() interface{} { return t.TextTask }
+
+func (t textPage) Request() *http.Request {
+	return t.p.req
+}
@@ -0,0 +1,125 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+type pageItemTask struct {
+	url string
+}
+
+func (t pageItemTask) Request() *http.Request { return getReq(t.url) }
+
+func (t pageItemTask) Handle(root *query.Node, s Storer) error {
+	items, err := Select(root, "li")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := s.Store(*item.Text()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nextLink(root *query.Node) *string {
+	a, err := SelectFirst(root, "a.next")
+	if err != nil || a == nil {
+		return nil
+	}
+	return a.Href()
+}
+
+// newPageServer serves /page/1 to /page/last, each with an item and a link to
+// the page returned by next.
+func newPageServer(last int, next func(n int) int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/page/"))
+		if err != nil || n < 1 || n > last {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<html><body><ul><li>item%d</li></ul>`, n)
+		if m := next(n); m > 0 {
+			fmt.Fprintf(w, `<a class="next" href="/page/%d">next</a>`, m)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+}
+
+func runPaged(t *testing.T, task *PagedTask) *memTx {
+	runner := SequentialRunner{
+		Client:       http.DefaultClient,
+		ErrorHandler: ErrorHandlerFunc(func(req *http.Request, err error) error { return err }),
+	}
+	tx := &memTx{}
+	if err := task.Run(runner, tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.committed != 1 {
+		t.Fatalf("expect a commit, got %d commits and %d rollbacks", tx.committed, tx.rolledBack)
+	}
+	return tx
+}
+
+func TestPagedTask(t *testing.T) {
+	srv := newPageServer(3, func(n int) int {
+		if n < 3 {
+			return n + 1
+		}
+		return 0
+	})
+	defer srv.Close()
+
+	tx := runPaged(t, &PagedTask{Task: pageItemTask{srv.URL + "/page/1"}, NextPage: NextHref(nextLink)})
+	if fmt.Sprint(tx.stored) != "[item1 item2 item3]" {
+		t.Fatalf("unexpected items %v", tx.stored)
+	}
+
+	// pages by number stop at the first page not found, which fails the
+	// transaction.
+	tx = &memTx{}
+	err := (&PagedTask{Task: pageItemTask{}, PageURL: srv.URL + "/page/%d", FirstPage: 2}).Run(SequentialRunner{
+		Client:       http.DefaultClient,
+		ErrorHandler: ErrorHandlerFunc(func(req *http.Request, err error) error { return nil }),
+	}, tx)
+	if err != nil || fmt.Sprint(tx.stored) != "[item2 item3]" || tx.rolledBack != 1 {
+		t.Fatalf("unexpected items %v, %v", tx.stored, err)
+	}
+}
+
+func TestPagedTaskMaxPages(t *testing.T) {
+	srv := newPageServer(100, func(n int) int { return n + 1 })
+	defer srv.Close()
+
+	tx := runPaged(t, &PagedTask{Task: pageItemTask{srv.URL + "/page/1"}, NextPage: NextHref(nextLink), MaxPages: 2})
+	if fmt.Sprint(tx.stored) != "[item1 item2]" {
+		t.Fatalf("unexpected items %v", tx.stored)
+	}
+}
+
+func TestPagedTaskVisited(t *testing.T) {
+	for _, next := range []func(n int) int{
+		func(n int) int { return n },       // links to itself
+		func(n int) int { return n%2 + 1 }, // 1 -> 2 -> 1
+	} {
+		srv := newPageServer(2, next)
+		tx := runPaged(t, &PagedTask{Task: pageItemTask{srv.URL + "/page/1"}, NextPage: NextHref(nextLink)})
+		srv.Close()
+		if len(tx.stored) > 2 {
+			t.Fatalf("expect a visited page not to be fetched again, got %v", tx.stored)
+		}
+	}
+}
@@ -96,8 +96,8 @@ func (d RetryDoer) Do(req *http.Request) (resp *http.Response, err error) {
 	return nil, err
 }
 
-// Run either HtmlTask, TextTask or Task. tx is commited if successful or
-// rollbacked if failed.
+// Run either HtmlTask, FeedTask, XMLTask, CSVTask, TextTask or Task. tx is
+// commited if successful or rollbacked if failed.
 func Run(runner Runner, tx Tx, tasks ...interface{}) error {
 	switch len(tasks) {
 	case 0:
@@ -0,0 +1,265 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrSessionExpired is returned by Session when a response still indicates an
+// expired session right after a successful login.
+var ErrSessionExpired = errors.New("session expired after re-login")
+
+// ExpiryDetector reports whether a response indicates that the session has
+// expired and a login is required.
+type ExpiryDetector interface {
+	Expired(resp *http.Response) bool
+}
+
+// ExpiryDetectorFunc converts a function object to an ExpiryDetector interface.
+type ExpiryDetectorFunc func(*http.Response) bool
+
+// Expired implements ExpiryDetector interface.
+func (f ExpiryDetectorFunc) Expired(resp *http.Response) bool {
+	return f(resp)
+}
+
+// StatusExpired returns an ExpiryDetector that treats any of the status codes
+// as an expired session.
+func StatusExpired(codes ...int) ExpiryDetector {
+	return ExpiryDetectorFunc(func(resp *http.Response) bool {
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ContentPeekSize is the number of bytes at the beginning of a response body
+// examined by ContentExpired.
+var ContentPeekSize int64 = 64 * 1024
+
+// ContentExpired returns an ExpiryDetector that treats a response body matching
+// the regular expression as an expired session, e.g. a login form rendered in
+// place of the requested page. Only the first ContentPeekSize bytes are
+// buffered and matched, and they are restored in front of the rest of the body
+// so that it can still be streamed by the task.
+func ContentExpired(pat string) ExpiryDetector {
+	re := regexp.MustCompile(pat)
+	return ExpiryDetectorFunc(func(resp *http.Response) bool {
+		buf, err := ioutil.ReadAll(io.LimitReader(resp.Body, ContentPeekSize))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+		if err != nil {
+			return false
+		}
+		return re.Match(buf)
+	})
+}
+
+// Session is a Doer that keeps a login session for the requests passing
+// through it. It logs in with a login task before the first request, and logs
+// in again whenever its ExpiryDetector reports an expired session, after which
+// the request is sent again transparently. The cookies are kept in the Jar of
+// the http.Client, so the Session should be used as the Client of a Runner.
+type Session struct {
+	client   *http.Client
+	login    Task
+	detector ExpiryDetector
+	gen      int // incremented on every successful login
+	mu       sync.Mutex
+}
+
+// NewSession creates a Session from an http.Client, a login task and an
+// ExpiryDetector. The login task provides the login request (usually a form
+// POST) and its Handle method should return an error if the login fails. A new
+// cookie jar is created if the client does not have one.
+func NewSession(client *http.Client, login Task, detector ExpiryDetector) *Session {
+	if client.Jar == nil {
+		client.Jar, _ = cookiejar.New(nil) // never returns an error.
+	}
+	return &Session{client: client, login: login, detector: detector}
+}
+
+// Login logs in explicitly, establishing the session cookies in the jar.
+func (s *Session) Login() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.doLogin()
+}
+
+func (s *Session) doLogin() error {
+	resp, err := s.client.Do(s.login.Request())
+	if err != nil {
+		s.login.Handle(nil) // notify that the login has failed.
+		return err
+	}
+	defer resp.Body.Close()
+	if err := s.login.Handle(resp); err != nil {
+		return err
+	}
+	s.gen++
+	return nil
+}
+
+// relogin logs in again unless another goroutine has already done so since
+// the generation gen was observed.
+func (s *Session) relogin(gen int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gen != gen {
+		return nil
+	}
+	return s.doLogin()
+}
+
+func (s *Session) generation() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gen
+}
+
+// Do implements the Doer interface.
+func (s *Session) Do(req *http.Request) (*http.Response, error) {
+	gen := s.generation()
+	if gen == 0 {
+		if err := s.relogin(gen); err != nil {
+			return nil, err
+		}
+		gen = s.generation()
+	}
+	resp, err := s.send(req, false)
+	if err != nil || !s.detector.Expired(resp) {
+		return resp, err
+	}
+	resp.Body.Close()
+	if err := s.relogin(gen); err != nil {
+		return nil, err
+	}
+	resp, err = s.send(req, true)
+	if err != nil {
+		return nil, err
+	}
+	if s.detector.Expired(resp) {
+		resp.Body.Close()
+		return nil, ErrSessionExpired
+	}
+	return resp, nil
+}
+
+// send sends a copy of req, because http.Client adds the cookies of its jar to
+// the request sent, which would be stale for a retry. The body is renewed by
+// GetBody for a retry.
+func (s *Session) send(req *http.Request, retry bool) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if retry && req.Body != nil {
+		if req.GetBody == nil {
+			return nil, errors.New("cannot resend a request without GetBody")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return s.client.Do(r)
+}
+
+// PersistentJar is an http.CookieJar that can be saved to and loaded from a
+// file, so that a session can be reused between runs.
+type PersistentJar struct {
+	jar     *cookiejar.Jar
+	file    string
+	entries map[string][]*http.Cookie // cookies set, keyed by URL
+	mu      sync.Mutex
+}
+
+// NewPersistentJar creates a PersistentJar bound to a file. Unexpired cookies
+// are loaded from the file if it exists.
+func NewPersistentJar(file string) (*PersistentJar, error) {
+	jar, _ := cookiejar.New(nil) // never returns an error.
+	j := &PersistentJar{jar: jar, file: file, entries: make(map[string][]*http.Cookie)}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return j, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries map[string][]*http.Cookie
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for rawurl, cookies := range entries {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, err
+		}
+		var alive []*http.Cookie
+		for _, c := range cookies {
+			if c.Expires.IsZero() || c.Expires.After(now) {
+				alive = append(alive, c)
+			}
+		}
+		j.SetCookies(u, alive)
+	}
+	return j, nil
+}
+
+// SetCookies implements the http.CookieJar interface.
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	for _, c := range cookies {
+		j.entries[key] = setCookie(j.entries[key], c)
+	}
+	j.jar.SetCookies(u, cookies)
+}
+
+// Cookies implements the http.CookieJar interface.
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Save writes the cookies to the file of the jar.
+func (j *PersistentJar) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.Create(j.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(j.entries)
+}
+
+// setCookie replaces a cookie of the same name, domain and path or appends it.
+func setCookie(cookies []*http.Cookie, c *http.Cookie) []*http.Cookie {
+	for i := range cookies {
+		if cookies[i].Name == c.Name && cookies[i].Domain == c.Domain &&
+			cookies[i].Path == c.Path {
+			cookies[i] = c
+			return cookies
+		}
+	}
+	return append(cookies, c)
+}
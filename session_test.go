@@ -0,0 +1,182 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sessionServer issues a token cookie on /login and serves /page only with
+// the current token, or always responds 401 if broken is set.
+type sessionServer struct {
+	*httptest.Server
+	token  int32
+	logins int32
+	broken bool
+}
+
+func newSessionServer() *sessionServer {
+	s := &sessionServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			atomic.AddInt32(&s.logins, 1)
+			http.SetCookie(w, &http.Cookie{Name: "token", Value: fmt.Sprint(atomic.LoadInt32(&s.token))})
+		case "/page":
+			c, err := r.Cookie("token")
+			if s.broken || err != nil || c.Value != fmt.Sprint(atomic.LoadInt32(&s.token)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "content")
+		}
+	}))
+	return s
+}
+
+// expire invalidates the token issued.
+func (s *sessionServer) expire() {
+	atomic.AddInt32(&s.token, 1)
+}
+
+type loginTask struct {
+	url string
+}
+
+func (t loginTask) Request() *http.Request {
+	req, _ := http.NewRequest("POST", t.url+"/login", nil)
+	return req
+}
+
+func (t loginTask) Handle(resp *http.Response) error {
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		return errors.New("login failed")
+	}
+	return nil
+}
+
+func getPage(t *testing.T, s *Session, u string) (string, error) {
+	req, _ := http.NewRequest("GET", u+"/page", nil)
+	resp, err := s.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	buf, err := ioutil.ReadAll(resp.Body)
+	return string(buf), err
+}
+
+func TestSessionLogin(t *testing.T) {
+	srv := newSessionServer()
+	defer srv.Close()
+	s := NewSession(&http.Client{}, loginTask{srv.URL}, StatusExpired(http.StatusUnauthorized))
+
+	if body, err := getPage(t, s, srv.URL); err != nil || body != "content" {
+		t.Fatalf("expect content, got %q, %v", body, err)
+	}
+	if srv.logins != 1 {
+		t.Fatalf("expect an initial login, got %d", srv.logins)
+	}
+
+	srv.expire()
+	if body, err := getPage(t, s, srv.URL); err != nil || body != "content" {
+		t.Fatalf("expect content after re-login, got %q, %v", body, err)
+	}
+	if srv.logins != 2 {
+		t.Fatalf("expect a re-login, got %d logins", srv.logins)
+	}
+}
+
+func TestSessionConcurrentRelogin(t *testing.T) {
+	srv := newSessionServer()
+	defer srv.Close()
+	s := NewSession(&http.Client{}, loginTask{srv.URL}, StatusExpired(http.StatusUnauthorized))
+	if err := s.Login(); err != nil {
+		t.Fatal(err)
+	}
+	srv.expire()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := getPage(t, s, srv.URL); err != nil || body != "content" {
+				t.Errorf("expect content, got %q, %v", body, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if srv.logins != 2 {
+		t.Fatalf("expect a single re-login, got %d logins", srv.logins)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	srv := newSessionServer()
+	defer srv.Close()
+	srv.broken = true
+	s := NewSession(&http.Client{}, loginTask{srv.URL}, StatusExpired(http.StatusUnauthorized))
+	if _, err := getPage(t, s, srv.URL); err != ErrSessionExpired {
+		t.Fatalf("expect ErrSessionExpired, got %v", err)
+	}
+}
+
+func TestContentExpired(t *testing.T) {
+	body := "<form id=login>" + strings.Repeat("x", int(ContentPeekSize))
+	resp := &http.Response{Body: ioutil.NopCloser(strings.NewReader(body))}
+	if !ContentExpired("id=login").Expired(resp) {
+		t.Fatal("expect expired")
+	}
+	buf, _ := ioutil.ReadAll(resp.Body)
+	if string(buf) != body {
+		t.Fatal("expect the body restored")
+	}
+
+	resp = &http.Response{Body: ioutil.NopCloser(strings.NewReader(strings.Repeat("x", int(ContentPeekSize)) + "id=login"))}
+	if ContentExpired("id=login").Expired(resp) {
+		t.Fatal("expect only the beginning of the body matched")
+	}
+}
+
+func TestPersistentJar(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cookies.json")
+	j, err := NewPersistentJar(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://example.com/")
+	j.SetCookies(u, []*http.Cookie{
+		{Name: "alive", Value: "1", Expires: time.Now().Add(time.Hour)},
+		{Name: "session", Value: "2"},
+		{Name: "expired", Value: "3", Expires: time.Now().Add(-time.Hour)},
+	})
+	if err := j.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = NewPersistentJar(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, c := range j.Cookies(u) {
+		names[c.Name] = true
+	}
+	if len(names) != 2 || !names["alive"] || !names["session"] {
+		t.Fatalf("unexpected cookies %v", names)
+	}
+}
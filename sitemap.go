@@ -0,0 +1,209 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SitemapEntry is a URL entry of a sitemap, or a sitemap entry of a sitemap
+// index.
+type SitemapEntry struct {
+	Loc        string    `xml:"loc"`
+	LastMod    time.Time `xml:"-"` // Zero if RawLastMod is missing or invalid
+	RawLastMod string    `xml:"lastmod"`
+	ChangeFreq string    `xml:"changefreq"`
+	Priority   float64   `xml:"priority"`
+}
+
+// SitemapTask walks sitemaps and emits a task for each URL found. The URL can
+// be a robots.txt with Sitemap declarations, a sitemap or a sitemap index,
+// optionally gzipped. Nested sitemap indexes are followed.
+type SitemapTask struct {
+	URLs []string // URLs of robots.txt, sitemaps or sitemap indexes
+
+	// Since filters out entries whose lastmod is not after it, so that an
+	// incremental crawl only enqueues pages changed since the previous run.
+	// Entries without lastmod are always kept.
+	Since time.Time
+
+	// NewTask creates an HTMLTask, TextTask or Task for an entry, or returns nil
+	// to skip it.
+	NewTask func(e *SitemapEntry) interface{}
+}
+
+// Run walks the sitemaps and runs the tasks created for the entries within a
+// single transaction tx (See Run function).
+func (t *SitemapTask) Run(runner Runner, tx Tx) error {
+	entries, err := t.Entries(runner)
+	if err != nil {
+		return err
+	}
+	var tasks []interface{}
+	for _, e := range entries {
+		if task := t.NewTask(e); task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return Run(runner, tx, tasks...)
+}
+
+// Entries walks the sitemaps by runner and returns the URL entries changed
+// since t.Since.
+func (t *SitemapTask) Entries(runner Runner) ([]*SitemapEntry, error) {
+	var entries []*SitemapEntry
+	queue := append([]string(nil), t.URLs...)
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if visited[u] {
+			continue
+		}
+		visited[u] = true
+
+		var sm sitemapXML
+		var robots []string
+		isRobots := strings.HasSuffix(strings.ToLower(u), "/robots.txt")
+		err := fetch(runner, u, func(r io.Reader) (err error) {
+			if isRobots {
+				robots, err = parseRobotsSitemaps(r)
+				return err
+			}
+			return decodeSitemap(r, &sm)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, u)
+		}
+		queue = append(queue, robots...)
+		for _, e := range sm.Sitemaps {
+			if t.changed(e) {
+				queue = append(queue, e.Loc)
+			}
+		}
+		for _, e := range sm.URLs {
+			if t.changed(e) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (t *SitemapTask) changed(e *SitemapEntry) bool {
+	return e.LastMod.IsZero() || e.LastMod.After(t.Since)
+}
+
+type sitemapXML struct {
+	URLs     []*SitemapEntry `xml:"url"`
+	Sitemaps []*SitemapEntry `xml:"sitemap"`
+}
+
+// decodeSitemap decodes a sitemap or sitemap index, gunzipping it if needed.
+func decodeSitemap(r io.Reader, sm *sitemapXML) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	} else {
+		r = br
+	}
+	if err := xml.NewDecoder(r).Decode(sm); err != nil {
+		return err
+	}
+	for _, es := range [][]*SitemapEntry{sm.URLs, sm.Sitemaps} {
+		for _, e := range es {
+			e.Loc = strings.TrimSpace(e.Loc)
+			if e.RawLastMod != "" {
+				// an invalid lastmod is left zero, so the entry is kept.
+				e.LastMod, _ = parseW3CTime(strings.TrimSpace(e.RawLastMod))
+			}
+		}
+	}
+	return nil
+}
+
+var w3cLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseW3CTime(s string) (time.Time, error) {
+	for _, layout := range w3cLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid lastmod: " + s)
+}
+
+// parseRobotsSitemaps returns the URLs of Sitemap declarations in robots.txt.
+func parseRobotsSitemaps(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i > 0 &&
+			strings.EqualFold(strings.TrimSpace(line[:i]), "sitemap") {
+			urls = append(urls, strings.TrimSpace(line[i+1:]))
+		}
+	}
+	return urls, scanner.Err()
+}
+
+// fetch gets a URL by runner and waits until handle has processed its body.
+func fetch(runner Runner, url string, handle func(r io.Reader) error) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	t := &fetchTask{req: req, handle: handle, done: make(chan struct{})}
+	if err := runner.Run(t); err != nil {
+		return err
+	}
+	<-t.done
+	return t.err
+}
+
+// fetchTask is a Task that records the result of handling a response body and
+// notifies its completion.
+type fetchTask struct {
+	req    *http.Request
+	handle func(r io.Reader) error
+	err    error
+	done   chan struct{}
+}
+
+func (t *fetchTask) Request() *http.Request {
+	return t.req
+}
+
+func (t *fetchTask) Handle(resp *http.Response) error {
+	defer close(t.done)
+	switch {
+	case resp == nil:
+		t.err = errors.New("failed to fetch")
+	case resp.StatusCode != http.StatusOK:
+		t.err = fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	default:
+		t.err = t.handle(resp.Body)
+	}
+	return t.err
+}
@@ -0,0 +1,89 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSitemapEntries(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := srv.URL
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nDisallow: /private\nSitemap: %s/index.xml\n sitemap : %s/plain.xml\n", u, u)
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex>
+				<sitemap><loc>%s/new.xml.gz</loc><lastmod>2014-06-01</lastmod></sitemap>
+				<sitemap><loc>%s/old.xml</loc><lastmod>2013-01-01</lastmod></sitemap>
+				<sitemap><loc>%s/nested.xml</loc></sitemap>
+			</sitemapindex>`, u, u, u)
+		case "/new.xml.gz":
+			var b bytes.Buffer
+			gw := gzip.NewWriter(&b)
+			fmt.Fprintf(gw, `<urlset>
+				<url><loc> %s/a </loc><lastmod>2014-06-01T10:00:00+00:00</lastmod></url>
+				<url><loc>%s/b</loc><lastmod>2013-06-01</lastmod></url>
+				<url><loc>%s/c</loc><lastmod>yesterday</lastmod></url>
+			</urlset>`, u, u, u)
+			gw.Close()
+			w.Write(b.Bytes())
+		case "/old.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/old</loc></url></urlset>`, u)
+		case "/nested.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/plain.xml</loc></sitemap></sitemapindex>`, u)
+		case "/plain.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/d</loc><priority>0.5</priority></url></urlset>`, u)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	runner := SequentialRunner{
+		Client:       http.DefaultClient,
+		ErrorHandler: ErrorHandlerFunc(func(req *http.Request, err error) error { return nil }),
+	}
+	task := &SitemapTask{
+		URLs:  []string{srv.URL + "/robots.txt"},
+		Since: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	entries, err := task.Entries(runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locs []string
+	for _, e := range entries {
+		locs = append(locs, e.Loc[len(srv.URL):])
+		switch e.Loc[len(srv.URL):] {
+		case "/c":
+			if !e.LastMod.IsZero() || e.RawLastMod != "yesterday" {
+				t.Fatalf("expect zero lastmod for an invalid one, got %v", e.LastMod)
+			}
+		case "/d":
+			if e.Priority != 0.5 {
+				t.Fatalf("unexpected priority %v", e.Priority)
+			}
+		}
+	}
+	sort.Strings(locs)
+	if want := []string{"/a", "/c", "/d"}; !reflect.DeepEqual(locs, want) {
+		t.Fatalf("expect %v, got %v", want, locs)
+	}
+
+	task.URLs = []string{srv.URL + "/missing.xml"}
+	if _, err := task.Entries(runner); err == nil {
+		t.Fatal("expect error for a missing sitemap")
+	}
+}
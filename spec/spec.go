@@ -0,0 +1,263 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package spec interprets scraper specs written in YAML or JSON, so that a source
+can be added without writing Go. A spec describes the seed URLs, pagination,
+item selectors, field extraction, type coercion and target table, e.g.
+
+	name: goblog
+	table: golang_blog_entry
+	seeds: [http://blog.golang.org/index]
+	items: "#content .blogtitle"
+	fields:
+	- {name: title, css: a, key: true}
+	- {name: url, css: a, attr: href}
+	- {name: tags, css: span.tags, optional: true}
+
+Each item is stored as a *schema.Record of the target table through any
+getgo.Tx. At least one field must be a key, so that a re-run updates the items
+instead of duplicating them.
+*/
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v2"
+)
+
+// Spec describes a scraper.
+type Spec struct {
+	Name       string      `yaml:"name" json:"name"`
+	Format     string      `yaml:"format" json:"format"` // html (default) or json
+	Seeds      []string    `yaml:"seeds" json:"seeds"`
+	Pagination *Pagination `yaml:"pagination" json:"pagination"`
+	Items      string      `yaml:"items" json:"items"` // CSS selector, or dot path for json
+	Fields     []*Field    `yaml:"fields" json:"fields"`
+	Table      string      `yaml:"table" json:"table"`
+}
+
+// Pagination describes how to get the following pages of a seed.
+type Pagination struct {
+	Next     string `yaml:"next" json:"next"`           // CSS selector of the next page link (html only)
+	URL      string `yaml:"url" json:"url"`             // URL template with a %d verb for the page number
+	First    int    `yaml:"first" json:"first"`         // Number of the first page
+	MaxPages int    `yaml:"max_pages" json:"max_pages"` // 0 means unlimited
+}
+
+// Field describes how to extract a column of the target table from an item.
+type Field struct {
+	Name     string `yaml:"name" json:"name"`         // Column name
+	CSS      string `yaml:"css" json:"css"`           // CSS selector within the item (html)
+	XPath    string `yaml:"xpath" json:"xpath"`       // XPath expression from the item (html)
+	Attr     string `yaml:"attr" json:"attr"`         // Attribute instead of text (html)
+	Path     string `yaml:"path" json:"path"`         // Dot path within the item (json)
+	Pattern  string `yaml:"pattern" json:"pattern"`   // Regexp whose first group is kept
+	Type     string `yaml:"type" json:"type"`         // string (default), int, float, bool or time
+	Layout   string `yaml:"layout" json:"layout"`     // Time layout of Go time package
+	Key      bool   `yaml:"key" json:"key"`           // Primary key of the table
+	Optional bool   `yaml:"optional" json:"optional"` // Stored as NULL instead of skipping the item
+
+	pattern *regexp.Regexp
+	xpath   *getgo.XPath
+}
+
+// Errors is a list of validation errors.
+type Errors []error
+
+func (es Errors) Error() string {
+	s := make([]string, len(es))
+	for i, e := range es {
+		s[i] = e.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Load loads a spec from a YAML or JSON file, according to its extension, and
+// validates it.
+func Load(file string) (*Spec, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var s *Spec
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		s, err = ParseJSON(buf)
+	default:
+		s, err = ParseYAML(buf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return s, nil
+}
+
+// ParseYAML parses and validates a spec in YAML.
+func ParseYAML(buf []byte) (*Spec, error) {
+	var s Spec
+	if err := yaml.UnmarshalStrict(buf, &s); err != nil {
+		return nil, err
+	}
+	return &s, s.Validate()
+}
+
+// ParseJSON parses and validates a spec in JSON.
+func ParseJSON(buf []byte) (*Spec, error) {
+	var s Spec
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, s.Validate()
+}
+
+// Validate checks the spec and compiles its expressions. All problems found
+// are returned as Errors, each prefixed with the location in the spec.
+func (s *Spec) Validate() error {
+	var errs Errors
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+	switch s.Format {
+	case "":
+		s.Format = "html"
+	case "html", "json":
+	default:
+		fail("format: unknown format %q, expect html or json", s.Format)
+	}
+	if s.Table == "" {
+		fail("table: missing target table")
+	}
+	for i, seed := range s.Seeds {
+		if u, err := url.Parse(seed); err != nil || !u.IsAbs() {
+			fail("seeds[%d]: invalid absolute URL %q", i, seed)
+		}
+	}
+	if p := s.Pagination; p != nil {
+		switch {
+		case p.Next != "" && p.URL != "":
+			fail("pagination: next and url are exclusive")
+		case p.Next == "" && p.URL == "":
+			fail("pagination: either next or url is required")
+		case p.Next != "" && s.Format != "html":
+			fail("pagination.next: only supported by html format")
+		case p.Next != "" && len(s.Seeds) != 1:
+			fail("pagination.next: exactly one seed is required, got %d", len(s.Seeds))
+		case p.URL != "" && strings.Count(p.URL, "%d") != 1:
+			fail("pagination.url: URL template must contain one %%d verb")
+		case p.URL != "" && len(s.Seeds) > 0:
+			fail("pagination.url: seeds and a URL template are exclusive")
+		}
+		if p.Next != "" {
+			if err := checkCSS(p.Next); err != nil {
+				fail("pagination.next: %v", err)
+			}
+		}
+		if p.MaxPages < 0 {
+			fail("pagination.max_pages: must not be negative")
+		}
+	} else if len(s.Seeds) == 0 {
+		fail("seeds: at least one seed or a pagination url is required")
+	}
+	if len(s.Fields) == 0 {
+		fail("fields: at least one field is required")
+	}
+	names := make(map[string]bool)
+	hasKey := false
+	for i, f := range s.Fields {
+		loc := fmt.Sprintf("fields[%d]", i)
+		if f == nil {
+			fail("%s: empty field", loc)
+			continue
+		}
+		if f.Name == "" {
+			fail("%s.name: missing column name", loc)
+		} else if names[f.Name] {
+			fail("%s.name: duplicate column %q", loc, f.Name)
+		}
+		names[f.Name] = true
+		if f.Key {
+			hasKey = true
+			if f.Optional {
+				fail("%s.optional: a key field cannot be optional", loc)
+			}
+		}
+		f.validate(s.Format, func(format string, a ...interface{}) {
+			fail(loc+"."+format, a...)
+		})
+	}
+	if len(s.Fields) > 0 && !hasKey {
+		fail("fields: at least one key field is required to upsert the items")
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (f *Field) validate(format string, fail func(string, ...interface{})) {
+	switch format {
+	case "html":
+		if f.CSS != "" && f.XPath != "" {
+			fail("css: css and xpath are exclusive")
+		}
+		if f.Path != "" {
+			fail("path: only supported by json format")
+		}
+		if f.CSS != "" {
+			if err := checkCSS(f.CSS); err != nil {
+				fail("css: %v", err)
+			}
+		}
+		if f.XPath != "" {
+			x, err := getgo.CompileXPath(f.XPath)
+			if err != nil {
+				fail("xpath: %v", err)
+			}
+			f.xpath = x
+		}
+	case "json":
+		if f.CSS != "" || f.XPath != "" || f.Attr != "" {
+			fail("css: css, xpath and attr are only supported by html format")
+		}
+	}
+	if f.Pattern != "" {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			fail("pattern: %v", err)
+		} else if re.NumSubexp() < 1 {
+			fail("pattern: a capturing group is required")
+		}
+		f.pattern = re
+	}
+	switch f.Type {
+	case "":
+		f.Type = "string"
+	case "string", "int", "float", "bool", "time":
+	default:
+		fail("type: unknown type %q, expect string, int, float, bool or time", f.Type)
+	}
+	if f.Layout != "" && f.Type != "time" {
+		fail("layout: only supported by time type")
+	}
+}
+
+// checkCSS validates a CSS selector.
+func checkCSS(sel string) error {
+	_, err := getgo.SelectFirst(query.NewNode(&html.Node{Type: html.DocumentNode}), sel)
+	return err
+}
@@ -0,0 +1,142 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spec
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/db/schema"
+)
+
+func TestParseYAML(t *testing.T) {
+	s, err := ParseYAML([]byte(`
+name: goblog
+table: golang_blog_entry
+seeds: [http://blog.golang.org/index]
+items: "#content .blogtitle"
+fields:
+- {name: title, css: a, key: true}
+- {name: url, css: a, attr: href}
+- {name: tags, css: span.tags, optional: true}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Format != "html" || len(s.Fields) != 3 || s.Fields[2].Type != "string" {
+		t.Errorf("unexpected spec %#v", s)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	_, err := ParseYAML([]byte(`
+seeds: [blog.golang.org]
+pagination: {next: "a.next", url: "http://x/?p=%d"}
+fields:
+- {name: title, css: "a[", type: integer}
+- {name: title, path: x, pattern: "\\d+"}
+`))
+	expected := []string{
+		`table: missing target table`,
+		`seeds[0]: invalid absolute URL "blog.golang.org"`,
+		`pagination: next and url are exclusive`,
+		`fields[0].css: `,
+		`fields[0].type: unknown type "integer", expect string, int, float, bool or time`,
+		`fields[1].name: duplicate column "title"`,
+		`fields[1].path: only supported by json format`,
+		`fields[1].pattern: a capturing group is required`,
+		`fields: at least one key field is required`,
+	}
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expect Errors, got %v", err)
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expect %d errors, got %d:\n%v", len(expected), len(errs), errs)
+	}
+	for i := range errs {
+		if !strings.HasPrefix(errs[i].Error(), expected[i]) {
+			t.Errorf("expect %q, got %q", expected[i], errs[i])
+		}
+	}
+}
+
+func TestValidatePaginationURL(t *testing.T) {
+	_, err := ParseYAML([]byte(`
+table: t
+seeds: [http://x/]
+pagination: {url: "http://x/?p=%d"}
+fields:
+- {name: id, css: a, key: true, optional: true}
+`))
+	expected := []string{
+		`pagination.url: seeds and a URL template are exclusive`,
+		`fields[0].optional: a key field cannot be optional`,
+	}
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != len(expected) {
+		t.Fatalf("expect %d errors, got %v", len(expected), err)
+	}
+	for i := range errs {
+		if errs[i].Error() != expected[i] {
+			t.Errorf("expect %q, got %q", expected[i], errs[i])
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	_, err := ParseJSON([]byte(`{"table": "t", "seeds": ["http://x/"], "feilds": []}`))
+	if err == nil || !strings.Contains(err.Error(), "feilds") {
+		t.Fatalf("expect an unknown field error, got %v", err)
+	}
+}
+
+type memTx struct {
+	records []*schema.Record
+}
+
+func (t *memTx) Store(v interface{}) error {
+	t.records = append(t.records, v.(*schema.Record))
+	return nil
+}
+
+func (t *memTx) Delete(v interface{}) error { return nil }
+func (t *memTx) Commit() error              { return nil }
+func (t *memTx) Rollback() error            { return nil }
+
+func TestRunWithoutValidate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<ul><li><a href="/a">A</a> <b>1,024</b></li><li><a href="/b">B</a> <b>2</b></li></ul>`)
+	}))
+	defer srv.Close()
+
+	s := &Spec{
+		Table: "links",
+		Seeds: []string{srv.URL},
+		Items: "li",
+		Fields: []*Field{
+			{Name: "url", XPath: "a", Attr: "href", Key: true},
+			{Name: "count", XPath: "b", Type: "int"},
+		},
+	}
+	runner := getgo.SequentialRunner{
+		Client:       http.DefaultClient,
+		ErrorHandler: getgo.ErrorHandlerFunc(func(req *http.Request, err error) error { return err }),
+	}
+	tx := &memTx{}
+	if err := s.Run(runner, tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.records) != 2 {
+		t.Fatalf("expect 2 records, got %d", len(tx.records))
+	}
+	if f := tx.records[0].Fields; f[0].Value != "/a" || f[1].Value != int64(1024) {
+		t.Fatalf("unexpected record %v %v", f[0].Value, f[1].Value)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/db/schema"
+	"github.com/hailiang/html-query"
+)
+
+// Run validates the spec, runs it by runner and stores the items into tx.
+// Without pagination, all seeds are run as a single transaction by getgo.Run.
+func (s *Spec) Run(runner getgo.Runner, tx getgo.Tx) error {
+	// a spec built in Go has its expressions compiled here.
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if p := s.Pagination; p != nil {
+		t := &getgo.PagedTask{
+			Task:      s.task(""),
+			PageURL:   p.URL,
+			FirstPage: p.First,
+			MaxPages:  p.MaxPages,
+		}
+		if p.Next != "" {
+			t.Task = s.task(s.Seeds[0])
+			t.NextPage = getgo.NextHref(func(root *query.Node) *string {
+				n, _ := getgo.SelectFirst(root, p.Next)
+				if n == nil {
+					return nil
+				}
+				href, _ := getgo.NodeAttr(n, "href")
+				return &href
+			})
+		}
+		return t.Run(runner, tx)
+	}
+	tasks := make([]interface{}, len(s.Seeds))
+	for i, seed := range s.Seeds {
+		tasks[i] = s.task(seed)
+	}
+	return getgo.Run(runner, tx, tasks...)
+}
+
+func (s *Spec) task(seed string) interface{} {
+	if s.Format == "json" {
+		return jsonTask{s, seed}
+	}
+	return htmlTask{s, seed}
+}
+
+type htmlTask struct {
+	spec *Spec
+	seed string
+}
+
+func (t htmlTask) Request() *http.Request {
+	return getReq(t.seed)
+}
+
+func (t htmlTask) Handle(root *query.Node, s getgo.Storer) error {
+	items := []*query.Node{root}
+	if t.spec.Items != "" {
+		var err error
+		if items, err = getgo.Select(root, t.spec.Items); err != nil {
+			return err
+		}
+	}
+	for _, item := range items {
+		values := make([]*string, len(t.spec.Fields))
+		for i, f := range t.spec.Fields {
+			values[i] = f.htmlValue(item)
+		}
+		if err := t.spec.store(values, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonTask struct {
+	spec *Spec
+	seed string
+}
+
+func (t jsonTask) Request() *http.Request {
+	return getReq(t.seed)
+}
+
+func (t jsonTask) Handle(r io.Reader, s getgo.Storer) error {
+	var v interface{}
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	root, _ := lookup(v, t.spec.Items)
+	items, ok := root.([]interface{})
+	if !ok {
+		items = []interface{}{root}
+	}
+	for _, item := range items {
+		values := make([]*string, len(t.spec.Fields))
+		for i, f := range t.spec.Fields {
+			if v, ok := lookup(item, f.Path); ok && v != nil {
+				str := fmt.Sprint(v)
+				values[i] = &str
+			}
+		}
+		if err := t.spec.store(values, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schema returns the table that the items are stored into, e.g. to create it
+// by sqlstore.DB.Migrate.
+func (s *Spec) Schema() *schema.Table {
+	t := &schema.Table{Name: s.Table}
+	for _, f := range s.Fields {
+		var typ reflect.Type
+		switch f.Type {
+		case "int":
+			typ = reflect.TypeOf(int64(0))
+		case "float":
+			typ = reflect.TypeOf(float64(0))
+		case "bool":
+			typ = reflect.TypeOf(false)
+		case "time":
+			typ = reflect.TypeOf(time.Time{})
+		default:
+			typ = reflect.TypeOf("")
+		}
+		t.Columns = append(t.Columns, &schema.Column{Name: f.Name, Type: typ, IsKey: f.Key, Nullable: f.Optional})
+	}
+	return t
+}
+
+// store converts the raw values of an item and stores it as a record, unless
+// a required field is missing.
+func (s *Spec) store(values []*string, st getgo.Storer) error {
+	r := &schema.Record{Name: s.Table}
+	for i, f := range s.Fields {
+		v, err := f.convert(values[i])
+		if err != nil {
+			return fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		if v == nil && !f.Optional {
+			return nil
+		}
+		r.Fields = append(r.Fields, &schema.Field{Name: f.Name, Value: v, IsKey: f.Key})
+	}
+	return st.Store(r)
+}
+
+func (f *Field) htmlValue(item *query.Node) *string {
+	n := item
+	switch {
+	case f.CSS != "":
+		if n, _ = getgo.SelectFirst(item, f.CSS); n == nil {
+			return nil
+		}
+	case f.xpath != nil:
+		nodes := f.xpath.Select(item)
+		if len(nodes) == 0 {
+			return nil
+		}
+		n = nodes[0]
+	}
+	if f.Attr != "" {
+		if v, ok := getgo.NodeAttr(n, f.Attr); ok {
+			return &v
+		}
+		return nil
+	}
+	s := getgo.NodeText(n)
+	return &s
+}
+
+// convert applies the pattern and converts a raw value to the field type. A
+// nil value is returned if the raw value is missing or not matched.
+func (f *Field) convert(raw *string) (interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	s := strings.TrimSpace(*raw)
+	if f.pattern != nil {
+		m := f.pattern.FindStringSubmatch(s)
+		if m == nil {
+			return nil, nil
+		}
+		s = m[1]
+	}
+	switch f.Type {
+	case "int":
+		return strconv.ParseInt(strings.Replace(s, ",", "", -1), 10, 64)
+	case "float":
+		return strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	case "time":
+		layout := f.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return time.Parse(layout, s)
+	}
+	return s, nil
+}
+
+// lookup returns the value of a dot path like "data.items.0.title" in a
+// decoded JSON value.
+func lookup(v interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch o := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = o[key]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(o) {
+				return nil, false
+			}
+			v = o[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func getReq(url string) *http.Request {
+	if url == "" {
+		return nil
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		panic(err) // seeds are validated.
+	}
+	return req
+}
@@ -0,0 +1,168 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	bodySnippetLen  = 512
+	maxRedirectsDef = 10
+)
+
+// StatusError is returned by the Storable adapter when a response has a status
+// code not accepted by the StatusPolicy of the task.
+type StatusError struct {
+	StatusCode int
+	URL        string
+	Body       string // leading part of the response body
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	e := &StatusError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		e.URL = resp.Request.URL.String()
+	}
+	buf, _ := ioutil.ReadAll(io.LimitReader(resp.Body, bodySnippetLen))
+	e.Body = string(buf)
+	return e
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// StatusPolicy decides how the Storable adapter handles the status code of a
+// response.
+type StatusPolicy struct {
+	Accept []int // Codes passed to the task, 200 and 202 if empty
+	Gone   []int // Codes meaning the resource is gone, see GoneHandler
+
+	// Redirect follows a 3xx response if not nil, otherwise a 3xx response is
+	// a StatusError. Note that an http.Client follows redirects by itself
+	// unless its CheckRedirect returns http.ErrUseLastResponse.
+	Redirect     Doer
+	MaxRedirects int // 10 if zero
+}
+
+// StatusPolicier is optionally implemented by a task to override the default
+// StatusPolicy.
+type StatusPolicier interface {
+	StatusPolicy() StatusPolicy
+}
+
+// GoneHandler is optionally implemented by a task to delete its stored record
+// when the response status means that the resource is gone. The Deleter is the
+// transaction of the task.
+type GoneHandler interface {
+	HandleGone(d Deleter) error
+}
+
+func (p *StatusPolicy) accepts(code int) bool {
+	if len(p.Accept) == 0 {
+		return code == http.StatusOK || code == http.StatusAccepted
+	}
+	return containsInt(p.Accept, code)
+}
+
+// follow follows redirects and returns the final response. Responses other
+// than resp are closed by the returned close function.
+func (p *StatusPolicy) follow(resp *http.Response) (*http.Response, func(), error) {
+	noop := func() {}
+	if p.Redirect == nil {
+		return resp, noop, nil
+	}
+	max := p.MaxRedirects
+	if max == 0 {
+		max = maxRedirectsDef
+	}
+	var opened []*http.Response
+	closeAll := func() {
+		for _, r := range opened {
+			r.Body.Close()
+		}
+	}
+	for i := 0; isRedirect(resp.StatusCode); i++ {
+		if i == max {
+			closeAll()
+			return nil, noop, errors.New("too many redirects")
+		}
+		loc, err := resp.Location()
+		if err != nil {
+			closeAll()
+			return nil, noop, err
+		}
+		req, err := http.NewRequest("GET", loc.String(), nil)
+		if err != nil {
+			closeAll()
+			return nil, noop, err
+		}
+		if resp, err = p.Redirect.Do(req); err != nil {
+			closeAll()
+			return nil, noop, err
+		}
+		opened = append(opened, resp)
+	}
+	return resp, closeAll, nil
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// deleteFrom deletes v from tx if it implements the Deleter interface.
+func deleteFrom(tx Storer, v interface{}) error {
+	d, ok := tx.(Deleter)
+	if !ok {
+		return errors.New("the transaction does not support Delete")
+	}
+	return d.Delete(v)
+}
+
+func containsInt(a []int, v int) bool {
+	for _, i := range a {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// taskStatusPolicy returns the StatusPolicy of a task wrapped in adapters.
+func taskStatusPolicy(t interface{}) StatusPolicy {
+	if p, ok := findTask(t, func(t interface{}) bool {
+		_, ok := t.(StatusPolicier)
+		return ok
+	}).(StatusPolicier); ok {
+		return p.StatusPolicy()
+	}
+	return StatusPolicy{}
+}
+
+// handleGone calls the GoneHandler of a task wrapped in adapters if any.
+func handleGone(t interface{}, s Storer) error {
+	h, ok := findTask(t, func(t interface{}) bool {
+		_, ok := t.(GoneHandler)
+		return ok
+	}).(GoneHandler)
+	if !ok {
+		return nil
+	}
+	d, ok := s.(Deleter)
+	if !ok {
+		return errors.New("the transaction does not support Delete")
+	}
+	return h.HandleGone(d)
+}
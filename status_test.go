@@ -0,0 +1,134 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type statusTask struct {
+	policy StatusPolicy
+	body   string // body handled
+	gone   int    // times HandleGone is called
+}
+
+func (t *statusTask) Request() *http.Request { return getReq("http://example.com/") }
+
+func (t *statusTask) StatusPolicy() StatusPolicy { return t.policy }
+
+func (t *statusTask) HandleGone(d Deleter) error {
+	t.gone++
+	return d.Delete("record")
+}
+
+func (t *statusTask) Handle(r io.Reader, s Storer) error {
+	buf, err := ioutil.ReadAll(r)
+	t.body = string(buf)
+	return err
+}
+
+// redirects is a Doer that redirects a path to another, or returns 200 with
+// the path as the body.
+type redirects map[string]string
+
+func (d redirects) Do(req *http.Request) (*http.Response, error) {
+	if to, ok := d[req.URL.Path]; ok {
+		resp := newResponse(http.StatusFound, "text/plain", "")
+		resp.Header.Set("Location", to)
+		resp.Request = req
+		return resp, nil
+	}
+	return newResponse(200, "text/plain", req.URL.Path), nil
+}
+
+func TestStatusPolicy(t *testing.T) {
+	for _, c := range []struct {
+		policy StatusPolicy
+		code   int
+		body   string // body handled, empty if not
+		status int    // code of the StatusError expected, 0 if none
+		gone   int
+	}{
+		{StatusPolicy{}, 200, "ok", 0, 0},
+		{StatusPolicy{}, 202, "ok", 0, 0},
+		{StatusPolicy{}, 204, "", 204, 0},
+		{StatusPolicy{}, 404, "", 404, 0},
+		{StatusPolicy{}, 410, "", 410, 0},
+		{StatusPolicy{}, 302, "", 302, 0},
+		{StatusPolicy{Accept: []int{200, 404}}, 404, "ok", 0, 0},
+		{StatusPolicy{Accept: []int{404}}, 200, "", 200, 0},
+		{StatusPolicy{Gone: []int{404, 410}}, 410, "", 0, 1},
+		{StatusPolicy{Gone: []int{404, 410}}, 404, "", 0, 1},
+		{StatusPolicy{Gone: []int{410}}, 500, "", 500, 0},
+	} {
+		task := &statusTask{policy: c.policy}
+		tx := &memTx{}
+		err := Storable{task}.Handle(newResponse(c.code, "text/plain", "ok"), tx)
+		if c.status != 0 {
+			se, ok := err.(*StatusError)
+			if !ok || se.StatusCode != c.status || se.URL != "http://example.com/" || se.Body != "ok" {
+				t.Errorf("%d %v: expect a StatusError, got %#v", c.code, c.policy, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d %v: %v", c.code, c.policy, err)
+		}
+		if task.body != c.body || task.gone != c.gone || len(tx.deleted) != c.gone {
+			t.Errorf("%d %v: expect body %q and %d gone, got %q and %d", c.code, c.policy, c.body, c.gone, task.body, task.gone)
+		}
+	}
+}
+
+func TestStatusGoneWithoutDeleter(t *testing.T) {
+	task := &statusTask{policy: StatusPolicy{Gone: []int{410}}}
+	s := struct{ Storer }{&memTx{}}
+	if err := (Storable{task}).Handle(newResponse(410, "text/plain", ""), s); err == nil {
+		t.Fatal("expect an error for a Storer without Delete")
+	}
+}
+
+func TestStatusRedirect(t *testing.T) {
+	doer := redirects{"/": "/a", "/a": "/b", "/loop": "/loop"}
+	for _, c := range []struct {
+		path string
+		max  int
+		body string
+		err  bool
+	}{
+		{"/", 0, "/b", false},
+		{"/", 2, "/b", false},
+		{"/", 1, "", true},
+		{"/loop", 0, "", true},
+	} {
+		task := &statusTask{policy: StatusPolicy{Redirect: doer, MaxRedirects: c.max}}
+		resp, _ := doer.Do(getReq("http://example.com" + c.path))
+		err := Storable{task}.Handle(resp, &memTx{})
+		if (err != nil) != c.err || task.body != c.body {
+			t.Errorf("%s %d: expect body %q, got %q, %v", c.path, c.max, c.body, task.body, err)
+		}
+	}
+
+	failing := doerFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("refused") })
+	resp := newResponse(http.StatusMovedPermanently, "text/plain", "")
+	resp.Header.Set("Location", "/next")
+	task := &statusTask{policy: StatusPolicy{Redirect: failing}}
+	if err := (Storable{task}).Handle(resp, &memTx{}); err == nil || err.Error() != "refused" {
+		t.Fatalf("expect the error of the redirect, got %v", err)
+	}
+}
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestStatusError(t *testing.T) {
+	err := newStatusError(newResponse(503, "text/html", string(make([]byte, bodySnippetLen+10))))
+	if err.Error() != "503 Service Unavailable: http://example.com/" || len(err.Body) != bodySnippetLen {
+		t.Fatalf("unexpected error %q with a body of %d bytes", err, len(err.Body))
+	}
+}
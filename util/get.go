@@ -6,18 +6,31 @@ package util
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 )
 
+// Get gets the response of a URL, or returns an error if the response code is
+// not 200.
+func Get(url string) (Response, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return Response{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return Response{}, fmt.Errorf("HTTP response code: %d.", resp.StatusCode)
+	}
+	return Response{resp.Body}, nil
+}
+
 // MustGet gets the response of a URL or panics if any error occurs.
 func MustGet(url string) Response {
-	resp, err := http.Get(url)
+	resp, err := Get(url)
 	checkError(err)
-	checkErrorf(resp.StatusCode != http.StatusOK,
-		"HTTP response code: %d.", resp.StatusCode)
-	return Response{resp.Body}
+	return resp
 }
 
 // Response provides convenient methods to save an HTTP response's body.
@@ -0,0 +1,277 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hailiang/getgo"
+	"github.com/hailiang/getgo/db/schema"
+)
+
+// Format is the output format of the records stored.
+type Format int
+
+// Output formats.
+const (
+	PrettyJSON Format = iota // Indented JSON values
+	JSONLines                // One JSON value per line
+	CSV                      // CSV with a header derived from the record type
+	Table                    // Aligned columns with a header
+)
+
+// ParseFormat returns the Format of a name: json, jsonl, csv or table.
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "json":
+		return PrettyJSON, nil
+	case "jsonl":
+		return JSONLines, nil
+	case "csv":
+		return CSV, nil
+	case "table":
+		return Table, nil
+	}
+	return 0, fmt.Errorf("unknown format %q, expect json, jsonl, csv or table", name)
+}
+
+// Options specifies how the records stored are written.
+type Options struct {
+	Format Format
+	Out    io.Writer // Records are written to it, os.Stdout if nil
+	Log    io.Writer // Commit and rollback markers are written to it, os.Stderr if nil
+}
+
+// NewPrinter returns a getgo.Tx that writes the records stored instead of
+// saving them. Records are buffered and written on Commit grouped by record
+// type, or discarded on Rollback. The Tx can be reused after Commit or
+// Rollback.
+//
+// The CSV format writes the header once before the first record, so all the
+// records stored must be of the same type.
+func NewPrinter(opts Options) getgo.Tx {
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	if opts.Log == nil {
+		opts.Log = os.Stderr
+	}
+	return &printerTx{opts: opts}
+}
+
+type printerTx struct {
+	opts       Options
+	groups     []*group
+	csvName    string // record type of the CSV output
+	csvWritten bool   // whether the CSV header is written
+	mu         sync.Mutex
+}
+
+// group is the records of the same type.
+type group struct {
+	name    string
+	header  []string
+	records []interface{}
+}
+
+func (t *printerTx) Store(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	name := recordName(v)
+	if t.opts.Format == CSV {
+		if t.csvName == "" {
+			t.csvName = name
+		} else if name != t.csvName {
+			return fmt.Errorf("CSV output of %s cannot contain %s", t.csvName, name)
+		}
+	}
+	for _, g := range t.groups {
+		if g.name == name {
+			g.records = append(g.records, v)
+			return nil
+		}
+	}
+	t.groups = append(t.groups, &group{name: name, header: header(v), records: []interface{}{v}})
+	return nil
+}
+
+func (t *printerTx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	groups := t.groups
+	t.groups = nil
+	for _, g := range groups {
+		if err := t.write(g); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(t.opts.Log, "Commited.")
+	return nil
+}
+
+func (t *printerTx) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.opts.Format == CSV && !t.csvWritten {
+		t.csvName = ""
+	}
+	t.groups = nil
+	fmt.Fprintln(t.opts.Log, "Rolled back.")
+	return nil
+}
+
+func (t *printerTx) write(g *group) error {
+	w := t.opts.Out
+	switch t.opts.Format {
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		for _, r := range g.records {
+			if err := enc.Encode(jsonValue(r)); err != nil {
+				return err
+			}
+		}
+	case CSV:
+		cw := csv.NewWriter(w)
+		if !t.csvWritten {
+			cw.Write(g.header)
+			t.csvWritten = true
+		}
+		for _, r := range g.records {
+			cw.Write(row(r, g.header))
+		}
+		cw.Flush()
+		return cw.Error()
+	case Table:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "# %s\n", g.name)
+		fmt.Fprintln(tw, strings.Join(g.header, "\t"))
+		for _, r := range g.records {
+			fmt.Fprintln(tw, strings.Join(row(r, g.header), "\t"))
+		}
+		fmt.Fprintln(tw)
+		return tw.Flush()
+	default:
+		for _, r := range g.records {
+			if _, err := fmt.Fprintln(w, toJSON(jsonValue(r))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordName returns the table name of a schema.Record or the type name.
+func recordName(v interface{}) string {
+	if r, ok := v.(*schema.Record); ok {
+		return r.Name
+	}
+	return indirect(reflect.TypeOf(v)).String()
+}
+
+// jsonValue converts a schema.Record to a map so that it is written as an
+// object of its fields.
+func jsonValue(v interface{}) interface{} {
+	r, ok := v.(*schema.Record)
+	if !ok {
+		return v
+	}
+	m := make(map[string]interface{}, len(r.Fields))
+	for _, f := range r.Fields {
+		m[f.Name] = f.Value
+	}
+	return m
+}
+
+// header returns the column names of a record: field names of a
+// schema.Record, exported fields of a struct, or "value" otherwise.
+func header(v interface{}) []string {
+	if r, ok := v.(*schema.Record); ok {
+		names := make([]string, len(r.Fields))
+		for i, f := range r.Fields {
+			names[i] = f.Name
+		}
+		return names
+	}
+	t := indirect(reflect.TypeOf(v))
+	if t.Kind() != reflect.Struct {
+		return []string{"value"}
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			names = append(names, t.Field(i).Name)
+		}
+	}
+	return names
+}
+
+// row returns the values of a record in the order of header.
+func row(v interface{}, header []string) []string {
+	values := make([]string, len(header))
+	if r, ok := v.(*schema.Record); ok {
+		for i, name := range header {
+			for _, f := range r.Fields {
+				if f.Name == name {
+					values[i] = format(f.Value)
+				}
+			}
+		}
+		return values
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		values[0] = format(v)
+		return values
+	}
+	for i, name := range header {
+		values[i] = format(rv.FieldByName(name).Interface())
+	}
+	return values
+}
+
+// format formats a value as a CSV or table cell, a nil pointer is empty.
+func format(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	switch x := rv.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case string:
+		return x
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		buf, _ := json.Marshal(rv.Interface())
+		return string(buf)
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
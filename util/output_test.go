@@ -0,0 +1,100 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hailiang/getgo/db/schema"
+)
+
+type entry struct {
+	Title string
+	Views *int
+	Date  time.Time
+	note  string
+}
+
+type other struct {
+	Name string
+}
+
+func printTo(format Format) (*bytes.Buffer, *bytes.Buffer, *printerTx) {
+	var out, log bytes.Buffer
+	return &out, &log, NewPrinter(Options{Format: format, Out: &out, Log: &log}).(*printerTx)
+}
+
+func TestPrinterFormats(t *testing.T) {
+	views := 3
+	date := time.Date(2014, 6, 2, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		format   Format
+		expected string
+	}{
+		{PrettyJSON, "{\n  \"Title\": \"a\",\n  \"Views\": 3,\n  \"Date\": \"2014-06-02T00:00:00Z\"\n}\n" +
+			"{\n  \"Title\": \"b\",\n  \"Views\": null,\n  \"Date\": \"0001-01-01T00:00:00Z\"\n}\n" +
+			"{\n  \"name\": \"x\"\n}\n"},
+		{JSONLines, `{"Title":"a","Views":3,"Date":"2014-06-02T00:00:00Z"}` + "\n" +
+			`{"Title":"b","Views":null,"Date":"0001-01-01T00:00:00Z"}` + "\n" +
+			`{"name":"x"}` + "\n"},
+		{Table, "# util.entry\nTitle  Views  Date\na      3      2014-06-02T00:00:00Z\nb             0001-01-01T00:00:00Z\n\n" +
+			"# pages\nname\nx\n\n"},
+	} {
+		out, log, p := printTo(c.format)
+		p.Store(&entry{Title: "a", Views: &views, Date: date})
+		p.Store(&schema.Record{Name: "pages", Fields: schema.Fields{schema.NewField("Name", "x", true)}})
+		p.Store(entry{Title: "b"})
+		if err := p.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("%d: expect\n%q\ngot\n%q", c.format, c.expected, out)
+		}
+		if log.String() != "Commited.\n" {
+			t.Errorf("unexpected log %q", log)
+		}
+	}
+}
+
+func TestPrinterCSV(t *testing.T) {
+	out, _, p := printTo(CSV)
+	p.Store(&entry{Title: "a, b"})
+	p.Commit()
+	p.Store(entry{Title: "c"})
+	p.Commit()
+	if expected := "Title,Views,Date\n\"a, b\",,0001-01-01T00:00:00Z\nc,,0001-01-01T00:00:00Z\n"; out.String() != expected {
+		t.Fatalf("expect a single header\n%q\ngot\n%q", expected, out)
+	}
+
+	// records of another type are rejected instead of mixed columns.
+	if err := p.Store(&other{"x"}); err == nil {
+		t.Fatal("expect an error for a record of another type")
+	}
+
+	// the type of a CSV output is reset by a rollback before any write.
+	out, _, p = printTo(CSV)
+	p.Store(&entry{Title: "a"})
+	p.Rollback()
+	if err := p.Store(&other{"x"}); err != nil {
+		t.Fatal(err)
+	}
+	p.Commit()
+	if out.String() != "Name\nx\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, format := range map[string]Format{"json": PrettyJSON, "jsonl": JSONLines, "csv": CSV, "table": Table} {
+		if f, err := ParseFormat(name); err != nil || f != format {
+			t.Errorf("%s: expect %d, got %d, %v", name, format, f, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expect an error for an unknown format")
+	}
+}
@@ -24,6 +24,24 @@ func MustLoadHTML(file string) *query.Node {
 	return node
 }
 
+// GetHTML gets and parses the HTML page of a URL.
+func GetHTML(url string) (*query.Node, error) {
+	resp, err := Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	return query.Parse(resp.rc)
+}
+
+// MustGetHTML gets and parses the HTML page of a URL or panics if any error
+// occurs.
+func MustGetHTML(url string) *query.Node {
+	node, err := GetHTML(url)
+	checkError(err)
+	return node
+}
+
 // MustLoadJSON loads and parses a JSON file.
 func MustLoadJSON(file string, v interface{}) {
 	f, err := os.Open(file)
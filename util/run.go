@@ -13,7 +13,12 @@ import (
 
 // Run runs tasks and print the data fetched.
 func Run(tasks ...interface{}) {
-	checkError(getgo.Run(runner(), printerTx{}, tasks...))
+	RunWith(Options{}, tasks...)
+}
+
+// RunWith runs tasks and writes the data fetched as specified by opts.
+func RunWith(opts Options, tasks ...interface{}) {
+	checkError(getgo.Run(runner(), NewPrinter(opts), tasks...))
 }
 
 func runner() getgo.Runner {
@@ -25,21 +30,3 @@ func runner() getgo.Runner {
 			return nil
 		})}
 }
-
-type printerTx struct {
-}
-
-func (printerTx) Store(v interface{}) error {
-	pp(v)
-	return nil
-}
-
-func (printerTx) Commit() error {
-	p("Commited.")
-	return nil
-}
-
-func (printerTx) Rollback() error {
-	p("Rolled back.")
-	return nil
-}
@@ -4,20 +4,7 @@
 
 package util
 
-import (
-	"encoding/json"
-	"fmt"
-)
-
-func p(v ...interface{}) {
-	fmt.Println(v...)
-}
-
-func pp(v ...interface{}) {
-	for _, item := range v {
-		fmt.Println(toJSON(item))
-	}
-}
+import "encoding/json"
 
 func toJSON(v interface{}) string {
 	s, _ := json.MarshalIndent(v, "", "  ")
@@ -29,9 +16,3 @@ func checkError(err error) {
 		panic(err)
 	}
 }
-
-func checkErrorf(cond bool, format string, a ...interface{}) {
-	if cond {
-		panic(fmt.Errorf(format, a...))
-	}
-}
@@ -0,0 +1,68 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// memTx is a Tx that keeps the records in memory.
+type memTx struct {
+	stored     []interface{}
+	deleted    []interface{}
+	committed  int
+	rolledBack int
+	mu         sync.Mutex
+}
+
+func (t *memTx) Store(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stored = append(t.stored, v)
+	return nil
+}
+
+func (t *memTx) Delete(v interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deleted = append(t.deleted, v)
+	return nil
+}
+
+func (t *memTx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.committed++
+	return nil
+}
+
+func (t *memTx) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rolledBack++
+	return nil
+}
+
+// newResponse returns a response of http://example.com/ with a status code, a
+// Content-Type and a body.
+func newResponse(code int, contentType, body string) *http.Response {
+	u, _ := url.Parse("http://example.com/")
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{Method: "GET", URL: u},
+	}
+}
+
+// getReq returns a GET request of a URL.
+func getReq(rawurl string) *http.Request {
+	req, _ := http.NewRequest("GET", rawurl, nil)
+	return req
+}
@@ -0,0 +1,30 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// ForEachElement walks the tokens of an XML document and calls visit for every
+// start element of the local name, at any depth. visit may decode the element
+// with d.DecodeElement, so that a very large document can be processed one
+// element at a time without being loaded into memory.
+func ForEachElement(d *xml.Decoder, local string, visit func(start *xml.StartElement) error) error {
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == local {
+			if err := visit(&start); err != nil {
+				return err
+			}
+		}
+	}
+}
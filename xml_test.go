@@ -0,0 +1,104 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type xmlCatalog struct {
+	Title string    `xml:"title"`
+	Books []xmlBook `xml:"books>book"`
+}
+
+type xmlBook struct {
+	ID    int    `xml:"id,attr"`
+	Title string `xml:"title"`
+}
+
+type xmlCatalogTask struct{}
+
+func (xmlCatalogTask) Request() *http.Request { return getReq("http://example.com/catalog.xml") }
+
+func (xmlCatalogTask) Handle(d *xml.Decoder, s Storer) error {
+	var c xmlCatalog
+	if err := d.Decode(&c); err != nil {
+		return err
+	}
+	return s.Store(&c)
+}
+
+func TestXMLText(t *testing.T) {
+	gbk := "\xc4\xe3\xba\xc3" // 你好 in GBK
+	body := `<?xml version="1.0" encoding="gbk"?>
+	<catalog>
+		<title>` + gbk + `</title>
+		<books><book id="1"><title>a</title></book><book id="2"><title>b</title></book></books>
+	</catalog>`
+	tx := &memTx{}
+	if err := (Storable{XMLText{xmlCatalogTask{}}}).Handle(newResponse(200, "application/xml", body), tx); err != nil {
+		t.Fatal(err)
+	}
+	c := tx.stored[0].(*xmlCatalog)
+	if c.Title != "你好" || len(c.Books) != 2 || c.Books[1].ID != 2 || c.Books[1].Title != "b" {
+		t.Fatalf("unexpected catalog %+v", c)
+	}
+}
+
+func TestForEachElement(t *testing.T) {
+	doc := `<feed xmlns:x="http://example.com/x">
+		<book id="1"><title>a</title></book>
+		<shelf>
+			<book id="2"><title>b</title></book>
+			<x:book id="3"><title>c</title></x:book>
+		</shelf>
+		<book id="4"><title>d</title><book id="5"><title>e</title></book></book>
+	</feed>`
+
+	// a decoded element is consumed with its children.
+	d := xml.NewDecoder(strings.NewReader(doc))
+	var ids []int
+	err := ForEachElement(d, "book", func(start *xml.StartElement) error {
+		var b xmlBook
+		if err := d.DecodeElement(&b, start); err != nil {
+			return err
+		}
+		ids = append(ids, b.ID)
+		return nil
+	})
+	if err != nil || fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Fatalf("unexpected books %v, %v", ids, err)
+	}
+
+	// an element not decoded is walked into.
+	var seen []string
+	err = ForEachElement(xml.NewDecoder(strings.NewReader(doc)), "book", func(start *xml.StartElement) error {
+		seen = append(seen, start.Attr[0].Value)
+		return nil
+	})
+	if err != nil || fmt.Sprint(seen) != "[1 2 3 4 5]" {
+		t.Fatalf("unexpected books %v, %v", seen, err)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = ForEachElement(xml.NewDecoder(strings.NewReader(doc)), "book", func(start *xml.StartElement) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Fatalf("expect to stop at the first error, got %v after %d", err, n)
+	}
+
+	if err := ForEachElement(xml.NewDecoder(strings.NewReader("<a><book></a>")), "book",
+		func(*xml.StartElement) error { return nil }); err == nil {
+		t.Fatal("expect an error for malformed XML")
+	}
+}
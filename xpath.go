@@ -0,0 +1,267 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xpath"
+	"github.com/hailiang/html-query"
+	"golang.org/x/net/html"
+)
+
+// XPath is a compiled XPath 1.0 expression that can be evaluated on a node
+// parsed by html-query. A relative expression (e.g. ".//a/@href") is evaluated
+// from the node given, while an absolute one from the root of its document.
+type XPath struct {
+	expr *xpath.Expr
+}
+
+// CompileXPath compiles an XPath 1.0 expression.
+func CompileXPath(expr string) (*XPath, error) {
+	e, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &XPath{e}, nil
+}
+
+// MustCompileXPath is like CompileXPath but panics if the expression is
+// invalid.
+func MustCompileXPath(expr string) *XPath {
+	x, err := CompileXPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// Evaluate evaluates the expression on node n. The result is a []*query.Node
+// for a node set (See Select), a string, a float64 or a bool.
+func (x *XPath) Evaluate(n *query.Node) interface{} {
+	if n == nil {
+		return nil
+	}
+	switch v := x.expr.Evaluate(newXPathNavigator(n.InternalNode())).(type) {
+	case *xpath.NodeIterator:
+		return toQueryNodes(iterNodes(v))
+	default:
+		return v
+	}
+}
+
+// Select returns the node set selected by the expression on node n. An
+// attribute is returned as a detached text node holding its value, as is a
+// string, number or boolean result.
+func (x *XPath) Select(n *query.Node) []*query.Node {
+	if n == nil {
+		return nil
+	}
+	return toQueryNodes(x.nodes(n.InternalNode()))
+}
+
+// String returns the string value of the expression on node n, which is the
+// value of the first node for a node set.
+func (x *XPath) String(n *query.Node) string {
+	if n == nil {
+		return ""
+	}
+	nodes := x.nodes(n.InternalNode())
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodeString(nodes[0])
+}
+
+func (x *XPath) nodes(n *html.Node) []*html.Node {
+	switch v := x.expr.Evaluate(newXPathNavigator(n)).(type) {
+	case *xpath.NodeIterator:
+		return iterNodes(v)
+	case string:
+		return []*html.Node{textNode(v)}
+	case float64:
+		return []*html.Node{textNode(strconv.FormatFloat(v, 'f', -1, 64))}
+	case bool:
+		return []*html.Node{textNode(strconv.FormatBool(v))}
+	}
+	return nil
+}
+
+func iterNodes(it *xpath.NodeIterator) []*html.Node {
+	var nodes []*html.Node
+	for it.MoveNext() {
+		nav := it.Current().(*xpathNavigator)
+		if nav.attr >= 0 {
+			nodes = append(nodes, textNode(nav.curr.Attr[nav.attr].Val))
+		} else {
+			nodes = append(nodes, nav.curr)
+		}
+	}
+	return nodes
+}
+
+func toQueryNodes(nodes []*html.Node) []*query.Node {
+	result := make([]*query.Node, len(nodes))
+	for i := range nodes {
+		result[i] = query.NewNode(nodes[i])
+	}
+	return result
+}
+
+func textNode(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
+
+// nodeString returns the XPath string value of a node, i.e. the concatenated
+// text of all its descendants.
+func nodeString(n *html.Node) string {
+	if n.Type == html.TextNode || n.Type == html.CommentNode {
+		return n.Data
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// xpathNavigator implements xpath.NodeNavigator over an html.Node tree.
+type xpathNavigator struct {
+	root, curr *html.Node
+	attr       int // index of the current attribute, -1 if on the node itself
+}
+
+func newXPathNavigator(n *html.Node) *xpathNavigator {
+	root := n
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return &xpathNavigator{root: root, curr: n, attr: -1}
+}
+
+func (x *xpathNavigator) NodeType() xpath.NodeType {
+	switch x.curr.Type {
+	case html.CommentNode:
+		return xpath.CommentNode
+	case html.TextNode:
+		return xpath.TextNode
+	case html.DocumentNode:
+		return xpath.RootNode
+	}
+	if x.attr >= 0 {
+		return xpath.AttributeNode
+	}
+	return xpath.ElementNode
+}
+
+func (x *xpathNavigator) LocalName() string {
+	if x.attr >= 0 {
+		return x.curr.Attr[x.attr].Key
+	}
+	return x.curr.Data
+}
+
+func (x *xpathNavigator) Prefix() string {
+	return ""
+}
+
+func (x *xpathNavigator) Value() string {
+	if x.attr >= 0 {
+		return x.curr.Attr[x.attr].Val
+	}
+	return nodeString(x.curr)
+}
+
+func (x *xpathNavigator) Copy() xpath.NodeNavigator {
+	n := *x
+	return &n
+}
+
+func (x *xpathNavigator) MoveToRoot() {
+	x.curr, x.attr = x.root, -1
+}
+
+func (x *xpathNavigator) MoveToParent() bool {
+	if x.attr >= 0 {
+		x.attr = -1
+		return true
+	}
+	if x.curr.Parent == nil {
+		return false
+	}
+	x.curr = x.curr.Parent
+	return true
+}
+
+func (x *xpathNavigator) MoveToNextAttribute() bool {
+	if x.attr >= len(x.curr.Attr)-1 {
+		return false
+	}
+	x.attr++
+	return true
+}
+
+func (x *xpathNavigator) MoveToChild() bool {
+	if x.attr >= 0 {
+		return false
+	}
+	return x.moveTo(skipDoctype(x.curr.FirstChild, nextSibling))
+}
+
+func (x *xpathNavigator) MoveToFirst() bool {
+	if x.attr >= 0 || x.curr.Parent == nil {
+		return false
+	}
+	return x.moveTo(skipDoctype(x.curr.Parent.FirstChild, nextSibling))
+}
+
+func (x *xpathNavigator) MoveToNext() bool {
+	if x.attr >= 0 {
+		return false
+	}
+	return x.moveTo(skipDoctype(x.curr.NextSibling, nextSibling))
+}
+
+func (x *xpathNavigator) MoveToPrevious() bool {
+	if x.attr >= 0 {
+		return false
+	}
+	return x.moveTo(skipDoctype(x.curr.PrevSibling, prevSibling))
+}
+
+func (x *xpathNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	o, ok := other.(*xpathNavigator)
+	if !ok || o.root != x.root {
+		return false
+	}
+	x.curr, x.attr = o.curr, o.attr
+	return true
+}
+
+func (x *xpathNavigator) moveTo(n *html.Node) bool {
+	if n == nil {
+		return false
+	}
+	x.curr = n
+	return true
+}
+
+func nextSibling(n *html.Node) *html.Node { return n.NextSibling }
+func prevSibling(n *html.Node) *html.Node { return n.PrevSibling }
+
+func skipDoctype(n *html.Node, next func(*html.Node) *html.Node) *html.Node {
+	for n != nil && n.Type == html.DoctypeNode {
+		n = next(n)
+	}
+	return n
+}
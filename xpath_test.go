@@ -0,0 +1,107 @@
+// Copyright 2014, Hǎiliàng Wáng. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package getgo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hailiang/html-query"
+)
+
+const xpathTestPage = `<html><body>
+<div id="a" class="item"><a href="/1">One</a><b>1</b></div>
+<div id="b" class="item"><a href="/2">Two</a><b>2</b><b>3</b></div>
+</body></html>`
+
+func nodeStrings(nodes []*query.Node) []string {
+	var s []string
+	for _, n := range nodes {
+		s = append(s, nodeString(n.InternalNode()))
+	}
+	return s
+}
+
+func TestXPathNodeSet(t *testing.T) {
+	root, err := query.Parse(strings.NewReader(xpathTestPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		expr string
+		want []string
+	}{
+		{"//div[@class='item']/a", []string{"One", "Two"}},
+		{"//a/@href", []string{"/1", "/2"}},
+		{"//div[count(b) > 1]/@id", []string{"b"}},
+		{"//div[@id='a']/following-sibling::div/a", []string{"Two"}},
+		{"//b[last()]", []string{"1", "3"}},
+		{"//p", nil},
+	} {
+		if got := nodeStrings(MustCompileXPath(c.expr).Select(root)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expect %v, got %v", c.expr, c.want, got)
+		}
+	}
+
+	div := MustCompileXPath("//div[@id='b']").Select(root)[0]
+	if got := nodeStrings(MustCompileXPath(".//b").Select(div)); !reflect.DeepEqual(got, []string{"2", "3"}) {
+		t.Errorf("expect a relative expression evaluated from the node, got %v", got)
+	}
+	if got := nodeStrings(MustCompileXPath("//a").Select(div)); len(got) != 2 {
+		t.Errorf("expect an absolute expression evaluated from the root, got %v", got)
+	}
+	if nodes, ok := MustCompileXPath("//a").Evaluate(root).([]*query.Node); !ok || len(nodes) != 2 {
+		t.Errorf("expect a node set, got %v", nodes)
+	}
+}
+
+func TestXPathScalar(t *testing.T) {
+	root, _ := query.Parse(strings.NewReader(xpathTestPage))
+	for _, c := range []struct {
+		expr string
+		want interface{}
+	}{
+		{"count(//b)", float64(3)},
+		{"sum(//b)", float64(6)},
+		{"string(//a/@href)", "/1"},
+		{"concat(//div[2]/@id, '-', //div[2]/a)", "b-Two"},
+		{"normalize-space(' x  y ')", "x y"},
+		{"boolean(//div[@id='b'])", true},
+		{"count(//b) > 5", false},
+	} {
+		if got := MustCompileXPath(c.expr).Evaluate(root); got != c.want {
+			t.Errorf("%s: expect %#v, got %#v", c.expr, c.want, got)
+		}
+	}
+	for expr, want := range map[string]string{
+		"count(//b)":                      "3",
+		"//a":                             "One",
+		"boolean(//p)":                    "false",
+		"//p":                             "",
+		"substring-after(//a/@href, '/')": "1",
+	} {
+		if got := MustCompileXPath(expr).String(root); got != want {
+			t.Errorf("%s: expect %q, got %q", expr, want, got)
+		}
+	}
+	if got := nodeStrings(MustCompileXPath("count(//a)").Select(root)); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("expect a scalar selected as a text node, got %v", got)
+	}
+}
+
+func TestXPathCompileError(t *testing.T) {
+	for _, expr := range []string{"//a[", "//a/@", "count(", "///", ""} {
+		if _, err := CompileXPath(expr); err == nil {
+			t.Errorf("%q: expect error", expr)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expect MustCompileXPath to panic")
+		}
+	}()
+	MustCompileXPath("//a[")
+}